@@ -6,120 +6,456 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/go-kit/log"
 	amqp "github.com/rabbitmq/amqp091-go"
-	"github.com/tarm/serial"
 )
 
 var (
-	ErrBackendPortOpen     = errors.New("Backend: port open error")
-	ErrBackendPortClose    = errors.New("Backend: port close error")
-	ErrBackendPortFlush    = errors.New("Backend: port flush error")
-	ErrBackendSlcanInit    = errors.New("Backend: SLCAN initialise error")
-	ErrBackendInvalidID    = errors.New("Backend: invalid ID")
-	ErrBackendInvalidData  = errors.New("Backend: invalid data")
-	ErrBackendInvalidFrame = errors.New("Backend: invalid frame")
-	ErrBackendReboot       = errors.New("Backend: reboot failed")
-	ErrBackendOnhold       = errors.New("Backend: on hold")
-	ErrBackendMsgQueue     = errors.New("Backend: message queue ping failed")
+	ErrBackendPortOpen      = errors.New("Backend: port open error")
+	ErrBackendPortClose     = errors.New("Backend: port close error")
+	ErrBackendPortFlush     = errors.New("Backend: port flush error")
+	ErrBackendSlcanInit     = errors.New("Backend: SLCAN initialise error")
+	ErrBackendInvalidID     = errors.New("Backend: invalid ID")
+	ErrBackendInvalidData   = errors.New("Backend: invalid data")
+	ErrBackendInvalidFrame  = errors.New("Backend: invalid frame")
+	ErrBackendReboot        = errors.New("Backend: reboot failed")
+	ErrBackendOnhold        = errors.New("Backend: on hold")
+	ErrBackendMsgQueue      = errors.New("Backend: message queue ping failed")
+	ErrBackendInvalidRate   = errors.New("Backend: invalid bitrate")
+	ErrBackendPortWrite     = errors.New("Backend: port write error")
+	ErrBackendNack          = errors.New("Backend: command rejected by device")
+	ErrBackendTimeout       = errors.New("Backend: device response timeout")
+	ErrBackendInvalidFilter = errors.New("Backend: invalid filter")
+	ErrBackendInvalidMode   = errors.New("Backend: invalid mode")
+	ErrBackendListenOnly    = errors.New("Backend: listen-only mode")
+	ErrBackendUnsupported   = errors.New("Backend: not supported by backend")
+	ErrBackendReconnecting  = errors.New("Backend: reconnecting to device")
+	ErrBackendClosed        = errors.New("Backend: shut down")
 )
 
+// slcanBitrates maps the standard CAN bus speeds to the SLCAN "Sn" presets
+var slcanBitrates = map[int]byte{
+	10000:   '0',
+	20000:   '1',
+	50000:   '2',
+	100000:  '3',
+	125000:  '4',
+	250000:  '5',
+	500000:  '6',
+	800000:  '7',
+	1000000: '8',
+}
+
+// slcanDataBitrates maps the CAN FD data phase speeds to the "Yn" presets
+var slcanDataBitrates = map[int]byte{
+	1000000: '1',
+	2000000: '2',
+	4000000: '4',
+	5000000: '5',
+	8000000: '8',
+}
+
+// Bitrate describes the CAN bus speed, either as one of the standard SLCAN
+// presets in bit/s (Rate) or as custom BTR0/BTR1 register values (BTR),
+// and for CAN FD the data phase speed in bit/s (Data). Zero fields leave
+// the device at its firmware default.
+type Bitrate struct {
+	Rate int    `json:"rate,omitempty" example:"500000"`
+	BTR  string `json:"btr,omitempty" example:"031c"`
+	Data int    `json:"data,omitempty" example:"2000000"`
+}
+
 type IBackend interface {
 	Handler(port string, baud int, url string) error
-	GetMessage(id int) error
+	Run(ctx context.Context, port string, baud int, url string) error
+	RemoteRequest(id int, dlc int, wait time.Duration) (Message, error)
 	PostMessage(m Message) error
 	Reboot() error
 	Unlock() error
+	GetBitrate() (Bitrate, error)
+	SetBitrate(br Bitrate) error
+	GetAcceptance() (Acceptance, error)
+	SetAcceptance(a Acceptance) error
+	GetFilters() ([]Filter, error)
+	SetFilters(f []Filter) error
+	SetMode(mode string) error
+	GetStatus() (Status, error)
+	GetDeviceInfo() (DeviceInfo, error)
+	QueryDeviceInfo() (DeviceInfo, error)
+	GetErrors() (ErrorReport, error)
+	ClearErrors() error
+	GetJobs() ([]Job, error)
+	GetJob(id int) (Job, error)
+	AddJob(j Job) (Job, error)
+	UpdateJob(id int, j Job) (Job, error)
+	DeleteJob(id int) error
+	StartJob(id int) (Job, error)
+	StopJob(id int) (Job, error)
+}
+
+// SOCKETCAN_SCHEME prefixes the interface name of SocketCAN ports, e.g.
+// can:vcan0
+const SOCKETCAN_SCHEME = "can:"
+
+const (
+	MODE_NORMAL      = "normal"
+	MODE_LISTEN_ONLY = "listen-only"
+)
+
+const (
+	LINK_CONNECTING   = "connecting"
+	LINK_CONNECTED    = "connected"
+	LINK_RECONNECTING = "reconnecting"
+	LINK_REBOOTING    = "rebooting"
+	LINK_CLOSED       = "closed"
+)
+
+// Status reports the state of the SLCAN channel. In listen-only mode the
+// device never acknowledges or transmits frames.
+type Status struct {
+	Mode string `json:"mode" example:"normal"`
+	// State of the link to the SLCAN device
+	Link string `json:"link" enums:"connecting,connected,reconnecting,rebooting,closed" example:"connected"`
+	// Number of times the link was reconnected after being lost
+	Reconnects int `json:"reconnects" example:"0"`
+	// Status flags last read from the SLCAN device
+	Flags StatusFlags `json:"flags"`
+	// Time the status flags were last read
+	UpdatedAt time.Time `json:"updated_at" example:"2023-06-01T12:00:00Z"`
+	// Controller state last reported by a state frame
+	State string `json:"state,omitempty" enums:"active,warning,passive,bus-off" example:"active"`
+	// Number of times the channel was reopened to recover from bus-off
+	Recoveries int `json:"recoveries" example:"0"`
+}
+
+// StatusFlags are the CAN controller status flags reported by the SLCAN
+// device in response to the "F" command.
+type StatusFlags struct {
+	RxFifoFull      bool `json:"rx_fifo_full"`
+	TxFifoFull      bool `json:"tx_fifo_full"`
+	ErrorWarning    bool `json:"error_warning"`
+	DataOverrun     bool `json:"data_overrun"`
+	ErrorPassive    bool `json:"error_passive"`
+	ArbitrationLost bool `json:"arbitration_lost"`
+	// Bus error interrupt, raised on any bus error rather than on bus-off
+	BusError bool `json:"bus_error"`
+}
+
+// DeviceInfo identifies the SLCAN device as reported by the "V", "v" and
+// "N" queries, refreshed whenever the channel is (re)opened.
+type DeviceInfo struct {
+	Hardware  string    `json:"hardware" example:"10"`
+	Software  string    `json:"software" example:"13"`
+	Version   string    `json:"version,omitempty" example:"3.1.0"`
+	Serial    string    `json:"serial" example:"A123"`
+	UpdatedAt time.Time `json:"updated_at" example:"2023-06-01T12:00:00Z"`
 }
 
 type Backend struct {
-	init chan bool
-	ch   chan Message
-	rst  chan bool
-	hold sync.Mutex
+	init    chan bool
+	ch      chan txRequest
+	rst     chan bool
+	cfg     chan configRequest
+	qry     chan chan error
+	hold    sync.Mutex
+	timeout time.Duration
+	logger  log.Logger
+
+	// Status flags polling and bus-off recovery
+	interval time.Duration
+	recover  bool
+
+	// Link reconnect backoff
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	// How long frames queued for transmission are waited for on shutdown
+	drain time.Duration
+
+	// CAN bus errors reported by the device
+	errlog *errorLog
+
+	// Byte stream to the SLCAN device
+	link Link
+
+	// Received frames
+	db Database
+
+	// Cyclic transmission jobs
+	sched *scheduler
+
+	mtx     sync.Mutex
+	conf    slcanConfig
+	filters []Filter
+	device  DeviceInfo
+	status  Status
+	waiters map[uint32][]chan Message
+
+	// Lines parsed by the reader and outstanding commands, owned by Handler
+	rx      chan rxLine
+	pending []pendingCmd
+	clock   timestampClock
+
+	// Outstanding status flags query, owned by Handler
+	polled      time.Time
+	statusDone  chan error
+	statusReply string
+
+	// Bus-off reported by a state frame, recovered from on the next tick,
+	// owned by Handler
+	busOff bool
 }
 
-func NewBackend() IBackend {
-	return &Backend{
-		init: make(chan bool),
-		ch:   make(chan Message),
-		rst:  make(chan bool),
-	}
+type txRequest struct {
+	m    Message
+	done chan error
 }
 
-func (b *Backend) Handler(port string, baud int, url string) error {
-	var s *serial.Port
-	var err error
-	var sl []byte
-	c := &serial.Config{Name: port, Baud: baud, ReadTimeout: time.Second}
+// slcanConfig is the SLCAN channel configuration applied whenever the
+// channel is opened
+type slcanConfig struct {
+	bitrate    Bitrate
+	acceptance Acceptance
+	tstamp     bool
+	listenOnly bool
+}
 
-	rlptr := 0
-	rl := make([]byte, len("T1234567880123456789abcdef\r\x00"))
-	rb := make([]byte, 1)
+type configRequest struct {
+	conf slcanConfig
+	done chan error
+}
 
-	s, err = serial.OpenPort(c)
-	if err != nil {
-		return ErrBackendPortOpen
+// pendingCmd is a command written to the SLCAN device which is waiting
+// for its ACK ('\r', 'z\r' or 'Z\r') or NACK ('\a') response.
+type pendingCmd struct {
+	done     chan error
+	reply    *string
+	deadline time.Time
+}
+
+// BackendOption sets an optional parameter for backends.
+type BackendOption func(*Backend)
+
+// WithBitrate sets the CAN bus bitrate applied whenever the SLCAN channel
+// is opened.
+func WithBitrate(br Bitrate) BackendOption {
+	return func(b *Backend) { b.conf.bitrate = br }
+}
+
+// WithTimeout sets how long the backend waits for the SLCAN device to
+// respond to a command or frame. Defaults to DefaultTimeout.
+func WithTimeout(d time.Duration) BackendOption {
+	return func(b *Backend) { b.timeout = d }
+}
+
+// WithTimestamp enables the SLCAN device to append a millisecond timestamp
+// to every received frame.
+func WithTimestamp(enable bool) BackendOption {
+	return func(b *Backend) { b.conf.tstamp = enable }
+}
+
+// WithAcceptance sets the acceptance code and mask programmed into the
+// SLCAN device whenever the channel is opened.
+func WithAcceptance(a Acceptance) BackendOption {
+	return func(b *Backend) { b.conf.acceptance = a }
+}
+
+// WithListenOnly opens the SLCAN channel in listen-only mode, where the
+// device never acknowledges or transmits frames.
+func WithListenOnly(enable bool) BackendOption {
+	return func(b *Backend) { b.conf.listenOnly = enable }
+}
+
+// WithFilters sets the software filters applied to received frames.
+func WithFilters(f []Filter) BackendOption {
+	return func(b *Backend) { b.filters = f }
+}
+
+// WithLogger sets the logger reporting SLCAN device status transitions.
+func WithLogger(l log.Logger) BackendOption {
+	return func(b *Backend) { b.logger = l }
+}
+
+// WithStatusInterval sets how often the SLCAN device status flags are
+// polled, zero disables polling. Defaults to DefaultStatusInterval.
+func WithStatusInterval(d time.Duration) BackendOption {
+	return func(b *Backend) { b.interval = d }
+}
+
+// WithBusOffRecovery has the backend close and reopen the SLCAN channel
+// whenever the device reports bus-off in a state frame.
+func WithBusOffRecovery(enable bool) BackendOption {
+	return func(b *Backend) { b.recover = enable }
+}
+
+// WithErrorLogSize sets how many CAN bus error events are kept. Defaults
+// to DefaultErrorLogSize.
+func WithErrorLogSize(n int) BackendOption {
+	return func(b *Backend) { b.errlog = newErrorLog(n) }
+}
+
+// WithLink sets the link to the SLCAN device. Defaults to the link chosen
+// by NewLink for the port passed to Handler.
+func WithLink(l Link) BackendOption {
+	return func(b *Backend) { b.link = l }
+}
+
+// WithReconnectBackoff sets how long the backend waits before reopening a
+// lost link, doubling from initial up to max between attempts. Defaults to
+// DefaultReconnectBackoff and DefaultReconnectMaxBackoff.
+func WithReconnectBackoff(initial, max time.Duration) BackendOption {
+	return func(b *Backend) { b.backoff, b.maxBackoff = initial, max }
+}
+
+// WithDrainTimeout sets how long Run waits on cancellation for frames
+// queued for transmission to be acknowledged. Defaults to
+// DefaultDrainTimeout.
+func WithDrainTimeout(d time.Duration) BackendOption {
+	return func(b *Backend) { b.drain = d }
+}
+
+// DefaultTimeout is the default SLCAN device response timeout.
+const DefaultTimeout = 500 * time.Millisecond
+
+// slcanTick is how often Handler expires commands and polls the status
+const slcanTick = 5 * time.Millisecond
+
+// DefaultStatusInterval is the default SLCAN device status polling interval.
+const DefaultStatusInterval = time.Second
+
+// DefaultReconnectBackoff and DefaultReconnectMaxBackoff are the default
+// initial and maximum waits between attempts to reopen a lost link.
+const (
+	DefaultReconnectBackoff    = 250 * time.Millisecond
+	DefaultReconnectMaxBackoff = 10 * time.Second
+)
+
+// DefaultDrainTimeout is the default wait for frames queued for
+// transmission on shutdown.
+const DefaultDrainTimeout = time.Second
+
+// slcanMaxLine is the longest line accepted from the SLCAN device, an
+// extended CAN FD frame with 64 bytes of data and a timestamp
+const slcanMaxLine = len("D12345678Fxxxx\r") + 64*2
+
+// NewBackend returns a backend writing the frames received to d.
+func NewBackend(d Database, options ...BackendOption) IBackend {
+	b := &Backend{
+		init:       make(chan bool, 1),
+		ch:         make(chan txRequest),
+		rst:        make(chan bool),
+		cfg:        make(chan configRequest),
+		qry:        make(chan chan error),
+		timeout:    DefaultTimeout,
+		logger:     log.NewNopLogger(),
+		interval:   DefaultStatusInterval,
+		backoff:    DefaultReconnectBackoff,
+		maxBackoff: DefaultReconnectMaxBackoff,
+		drain:      DefaultDrainTimeout,
+		db:         d,
+		status:     Status{Link: LINK_CONNECTING},
+		errlog:     newErrorLog(DefaultErrorLogSize),
+		waiters:    make(map[uint32][]chan Message),
+	}
+	for _, option := range options {
+		option(b)
 	}
+	b.sched = newScheduler(b.PostMessage, b.GetStatus)
+	return b
+}
 
-	err = s.Flush()
-	if err != nil {
-		return ErrBackendPortFlush
+// Handler runs the backend until the link to the SLCAN device fails for
+// good, see Run.
+func (b *Backend) Handler(port string, baud int, url string) error {
+	return b.Run(context.Background(), port, baud, url)
+}
+
+// Run opens the SLCAN device and serves frontend requests until ctx is
+// cancelled. Frames already queued for transmission are then given
+// DefaultDrainTimeout, or the WithDrainTimeout setting, to be acknowledged
+// before the SLCAN channel and the link are closed. A clean shutdown
+// returns nil.
+func (b *Backend) Run(ctx context.Context, port string, baud int, url string) error {
+	var err error
+	if b.link == nil {
+		b.link = NewLink(port)
 	}
+	s := b.link
 
-	// Initialise SLCAN port
-	_, err = s.Write([]byte("C\rO\r\x00"))
-	if err != nil {
-		return ErrBackendSlcanInit
+	if err = b.connect(s, port, baud); err != nil {
+		// Requests to a channel that never came up are turned away
+		b.setLink(LINK_CLOSED)
+		b.hold.Lock()
+		b.sched.close()
+		return err
 	}
+	b.setLink(LINK_CONNECTED)
 
+	tick := time.NewTicker(slcanTick)
+	defer tick.Stop()
 	for {
 		select {
-		case <-b.init:
-			s, err = serial.OpenPort(c)
-			if err != nil {
-				return ErrBackendPortOpen
-			}
+		case <-ctx.Done():
+			// Frontend requests are already held off during a firmware update
+			st, _ := b.GetStatus()
+			return b.shutdown(s, st.Link == LINK_REBOOTING)
 
-			err = s.Flush()
-			if err != nil {
-				return ErrBackendPortFlush
+		case <-b.init:
+			// Unlock requests racing each other are served once
+			if !b.swapLink(LINK_REBOOTING, LINK_RECONNECTING) {
+				break
 			}
-
-			// Initialise SLCAN port
-			_, err = s.Write([]byte("C\rO\r\x00"))
-			if err != nil {
-				return ErrBackendSlcanInit
+			// SLCAN device boots the updated firmware, which may take a while
+			// to enumerate again. Device info is refreshed on the way.
+			if err = b.redial(ctx, s, port, baud); err != nil {
+				return b.shutdown(s, true)
 			}
 
 			// To allow frontend requests to access serial backend
 			b.hold.Unlock()
 
-		case m := <-b.ch:
-			if sl, err = encapsSlcanFrame(m); err == nil {
-				_, err = s.Write([]byte(sl))
-			} else {
-				return err
+		case r := <-b.ch:
+			b.transmit(s, r)
+
+		case done := <-b.qry:
+			done <- b.query(s)
+
+		case r := <-b.cfg:
+			// Close SLCAN channel, apply new configuration and reopen
+			if err = b.open(s, r.conf); err != nil {
+				r.done <- err
+				break
 			}
+			b.mtx.Lock()
+			b.conf = r.conf
+			b.mtx.Unlock()
+			r.done <- nil
+
 		case <-b.rst:
 			// Frontend receives "Reboot" request, prompt SLCAN device to reset
+			b.setLink(LINK_REBOOTING)
+			// To prevent frontend requests from accessing serial backend,
+			// turning away those already on their way
+			b.acquire(ErrBackendOnhold)
 			if _, err = s.Write([]byte("bbbbbb\r\x00")); err != nil {
 				return ErrBackendReboot
 			}
+			// Device is going away, outstanding commands are never answered
+			b.flushPending(ErrBackendOnhold)
 			// Wait for SLCAN device to reboot
 			time.Sleep(3 * time.Second)
 			// SLCAN boots into MCUboot, prompt MCUboot to enter serial recovery mode
 			if _, err := s.Write([]byte("bbbbbb")); err != nil {
 				return ErrBackendReboot
 			}
-			// To prevent frontend requests from accessing serial backend
-			b.hold.Lock()
 			// Wait for any ongoing serial transactions to complete
 			time.Sleep(3 * time.Second)
 			// Close serial connection
-			if err := s.Close(); err != nil {
+			if err := b.disconnect(s); err != nil {
 				return ErrBackendPortOpen
 			}
 			// Ping MCUmgr service for firmware update
@@ -127,50 +463,519 @@ func (b *Backend) Handler(port string, baud int, url string) error {
 				return err
 			}
 
-		default:
-			if n, err := s.Read(rb); n > 0 && err == nil {
-				rl[rlptr] = rb[0]
-				rlptr += 1
-				if rlptr >= len("T1234567880123456789abcdef\r\x00") {
-					rlptr = 0
-				} else {
-					if rl[rlptr-1] == byte('\r') || rl[rlptr-1] == byte('\n') {
-						rl[rlptr] = byte('\x00')
-						rlptr = 0
-						if m, err := decapsSlcanFrame(rl); err == nil {
-							_ = db.WriteData(m)
-						} else {
-						}
-					}
+		case l, ok := <-b.rx:
+			// No lines are received while the link is closed for a firmware
+			// update, as b.rx is nil then
+			if !ok || l.err != nil {
+				b.logger.Log("event", "link lost", "err", l.err)
+				if err = b.reconnect(ctx, s, port, baud); err != nil {
+					return b.shutdown(s, true)
 				}
-			} else {
+				break
 			}
+			b.handle(l)
 
-			time.Sleep(500 * time.Microsecond)
+		case <-tick.C:
+			b.expire()
+			b.pollStatus(s)
+			b.recoverBusOff(s)
 		}
 	}
 }
 
-func (b *Backend) GetMessage(id int) error {
-	if !b.hold.TryLock() {
-		return ErrBackendOnhold
+// connect opens the link, initialises the SLCAN device with the current
+// configuration and queries the device info
+func (b *Backend) connect(s Link, port string, baud int) error {
+	if err := s.Open(port, baud); err != nil {
+		return ErrBackendPortOpen
 	}
-	defer b.hold.Unlock()
+	if err := s.Flush(); err != nil {
+		_ = s.Close()
+		return ErrBackendPortFlush
+	}
+
+	// Initialise SLCAN port
+	b.statusDone = nil
+	b.rx = make(chan rxLine, rxQueueSize)
+	go reader(s, b.rx)
+	if err := b.open(s, b.getConfig()); err != nil {
+		_ = b.disconnect(s)
+		return err
+	}
+	// Not every device answers the queries, leave device info empty then
+	_ = b.query(s)
 	return nil
 }
 
-func (b *Backend) PostMessage(m Message) error {
-	if !b.hold.TryLock() {
-		return ErrBackendOnhold
+// transmit writes a frame requested by the frontend to the SLCAN device
+func (b *Backend) transmit(s Link, r txRequest) {
+	sl, err := encapsSlcanFrame(r.m)
+	if err != nil {
+		r.done <- err
+		return
 	}
-	defer b.hold.Unlock()
-	b.ch <- m
+	if _, err = s.Write(sl); err != nil {
+		r.done <- ErrBackendPortWrite
+		return
+	}
+	// Device confirms with 'z' or 'Z', or rejects with BELL
+	b.expect(r.done, nil)
+}
+
+// reconnect recovers from a lost link, holding off frontend requests until
+// the SLCAN device is back. It gives up, still holding them off, once ctx
+// is cancelled.
+func (b *Backend) reconnect(ctx context.Context, s Link, port string, baud int) error {
+	b.setLink(LINK_RECONNECTING)
+	b.flushPending(ErrBackendReconnecting)
+	_ = b.disconnect(s)
+	b.acquire(ErrBackendReconnecting)
+	if err := b.redial(ctx, s, port, baud); err != nil {
+		return err
+	}
+	b.mtx.Lock()
+	b.status.Reconnects += 1
+	b.mtx.Unlock()
+	b.hold.Unlock()
+	return nil
+}
+
+// shutdown turns frontend requests away, waits for frames already queued
+// for transmission, then closes the SLCAN channel and the link. held tells
+// whether frontend requests are held off already.
+func (b *Backend) shutdown(s Link, held bool) error {
+	b.setLink(LINK_CLOSED)
+	b.sched.close()
+	deadline := time.NewTimer(b.drain)
+	defer deadline.Stop()
+
+	// Requests on their way to Handler were accepted, transmit them
+	for !held && !b.hold.TryLock() {
+		select {
+		case r := <-b.ch:
+			b.transmit(s, r)
+		case r := <-b.cfg:
+			r.done <- ErrBackendClosed
+		case done := <-b.qry:
+			done <- ErrBackendClosed
+		case <-b.rst:
+		case <-time.After(time.Millisecond):
+		}
+	}
+	for len(b.pending) > 0 && b.rx != nil {
+		select {
+		case l, ok := <-b.rx:
+			if !ok || l.err != nil {
+				b.flushPending(ErrLinkDown)
+				break
+			}
+			b.handle(l)
+		case <-deadline.C:
+			b.flushPending(ErrBackendTimeout)
+		case <-time.After(slcanTick):
+			b.expire()
+		}
+	}
+
+	// Leave the device with its channel closed, unless the link is gone
+	if b.rx != nil {
+		if _, err := b.exec(s, []byte("C\r")); err != nil {
+			b.logger.Log("event", "shutdown", "err", err)
+		}
+	}
+	_ = b.disconnect(s)
+	b.logger.Log("event", "shutdown", "link", LINK_CLOSED)
 	return nil
 }
 
+// disconnect closes the link and waits for the reader to stop
+func (b *Backend) disconnect(s Link) error {
+	err := s.Close()
+	if b.rx != nil {
+		for range b.rx {
+		}
+		b.rx = nil
+	}
+	return err
+}
+
+// acquire takes the hold over frontend requests, turning away requests
+// already on their way to Handler with err
+func (b *Backend) acquire(err error) {
+	for !b.hold.TryLock() {
+		select {
+		case r := <-b.ch:
+			r.done <- err
+		case r := <-b.cfg:
+			r.done <- err
+		case done := <-b.qry:
+			done <- err
+		case <-b.rst:
+			// SLCAN device is going away already, nothing to reboot
+		case <-time.After(time.Millisecond):
+		}
+	}
+}
+
+// redial reopens the link with exponential backoff, until the SLCAN device
+// is initialised again or ctx is cancelled
+func (b *Backend) redial(ctx context.Context, s Link, port string, baud int) error {
+	return b.retry(ctx, func() error { return b.connect(s, port, baud) })
+}
+
+// retry calls dial with exponential backoff, holding the link in
+// reconnecting state until dial succeeds or ctx is cancelled
+func (b *Backend) retry(ctx context.Context, dial func() error) error {
+	b.setLink(LINK_RECONNECTING)
+	d := b.backoff
+	for {
+		err := dial()
+		if err == nil {
+			break
+		}
+		b.logger.Log("event", "reconnect", "retry", d, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+		}
+		if d *= 2; d > b.maxBackoff {
+			d = b.maxBackoff
+		}
+	}
+	b.setLink(LINK_CONNECTED)
+	b.logger.Log("event", "reconnect", "link", LINK_CONNECTED)
+	return nil
+}
+
+// open closes the SLCAN channel, applies the configuration and opens the
+// channel again, waiting for the device to confirm each command
+func (b *Backend) open(s Link, c slcanConfig) error {
+	seq, err := openSeq(c)
+	if err != nil {
+		return err
+	}
+	b.clock = timestampClock{}
+	for i, cmd := range seq {
+		_, err := b.exec(s, cmd)
+		// Closing an already closed channel is rejected by some devices
+		if i == 0 && err == ErrBackendNack {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exec writes a command to the SLCAN device and polls the port until the
+// device responds or the response times out, returning the reply to queries
+func (b *Backend) exec(s Link, cmd []byte) (string, error) {
+	var reply string
+	done := make(chan error, 1)
+	if _, err := s.Write(cmd); err != nil {
+		return "", ErrBackendSlcanInit
+	}
+	b.expect(done, &reply)
+	tick := time.NewTicker(slcanTick)
+	defer tick.Stop()
+	for {
+		select {
+		case err := <-done:
+			return reply, err
+		case l, ok := <-b.rx:
+			if !ok || l.err != nil {
+				b.flushPending(ErrLinkDown)
+				return "", <-done
+			}
+			b.handle(l)
+		case <-tick.C:
+			b.expire()
+		}
+	}
+}
+
+// query asks the SLCAN device for its versions and serial number, and
+// caches the replies
+func (b *Backend) query(s Link) error {
+	var d DeviceInfo
+	r, err := b.exec(s, []byte("V\r"))
+	if err != nil {
+		return err
+	}
+	d.Hardware, d.Software = parseSlcanVersion(r)
+	// Detailed version is not supported by every device
+	if r, err := b.exec(s, []byte("v\r")); err == nil {
+		d.Version = r
+	}
+	if d.Serial, err = b.exec(s, []byte("N\r")); err != nil {
+		return err
+	}
+	d.UpdatedAt = time.Now()
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.device = d
+	return nil
+}
+
+// expect queues a response waiter for a command just written to the
+// device, reply receives the payload of query responses
+func (b *Backend) expect(done chan error, reply *string) {
+	b.pending = append(b.pending, pendingCmd{
+		done:     done,
+		reply:    reply,
+		deadline: time.Now().Add(b.timeout),
+	})
+}
+
+// resolve answers the oldest outstanding command, as SLCAN devices respond
+// to commands in order
+func (b *Backend) resolve(err error) {
+	b.resolveReply("", err)
+}
+
+func (b *Backend) resolveReply(r string, err error) {
+	if len(b.pending) == 0 {
+		return
+	}
+	if b.pending[0].reply != nil {
+		*b.pending[0].reply = r
+	}
+	b.pending[0].done <- err
+	b.pending = b.pending[1:]
+}
+
+// flushPending answers every outstanding command with err
+func (b *Backend) flushPending(err error) {
+	for len(b.pending) > 0 {
+		b.resolve(err)
+	}
+}
+
+// expire fails outstanding commands the device did not answer in time
+func (b *Backend) expire() {
+	now := time.Now()
+	for len(b.pending) > 0 && now.After(b.pending[0].deadline) {
+		b.resolve(ErrBackendTimeout)
+	}
+}
+
+// pollStatus periodically queries the SLCAN device status flags, and
+// handles the reply once the device responds
+func (b *Backend) pollStatus(s Link) {
+	if b.statusDone != nil {
+		select {
+		case err := <-b.statusDone:
+			b.statusDone = nil
+			if err != nil {
+				break
+			}
+			f, err := decodeStatusFlags(b.statusReply)
+			if err != nil {
+				break
+			}
+			b.updateStatus(f)
+		default:
+		}
+		return
+	}
+
+	if b.interval <= 0 || time.Since(b.polled) < b.interval {
+		return
+	}
+	b.polled = time.Now()
+	if _, err := s.Write([]byte("F\r")); err != nil {
+		return
+	}
+	b.statusDone = make(chan error, 1)
+	b.expect(b.statusDone, &b.statusReply)
+}
+
+// recoverBusOff closes and reopens the SLCAN channel once the device has
+// reported bus-off, when enabled with WithBusOffRecovery
+func (b *Backend) recoverBusOff(s Link) {
+	if !b.busOff {
+		return
+	}
+	b.busOff = false
+	b.logger.Log("event", "bus-off", "action", "reopen")
+	err := b.open(s, b.getConfig())
+	b.logger.Log("event", "reopen", "err", err)
+	if err == nil {
+		b.mtx.Lock()
+		b.status.Recoveries += 1
+		b.mtx.Unlock()
+	}
+}
+
+// updateStatus stores the status flags and logs the flags which changed
+func (b *Backend) updateStatus(f StatusFlags) {
+	b.mtx.Lock()
+	prev := b.status.Flags
+	b.status.Flags = f
+	b.status.UpdatedAt = time.Now()
+	b.mtx.Unlock()
+
+	for _, c := range []struct {
+		name      string
+		prev, cur bool
+	}{
+		{"rx_fifo_full", prev.RxFifoFull, f.RxFifoFull},
+		{"tx_fifo_full", prev.TxFifoFull, f.TxFifoFull},
+		{"error_warning", prev.ErrorWarning, f.ErrorWarning},
+		{"data_overrun", prev.DataOverrun, f.DataOverrun},
+		{"error_passive", prev.ErrorPassive, f.ErrorPassive},
+		{"arbitration_lost", prev.ArbitrationLost, f.ArbitrationLost},
+		{"bus_error", prev.BusError, f.BusError},
+	} {
+		if c.prev != c.cur {
+			b.logger.Log("flag", c.name, "set", c.cur)
+		}
+	}
+}
+
+// updateState stores the controller state reported by a state frame, logs
+// transitions and reports whether the controller has just entered bus-off
+func (b *Backend) updateState(state string) bool {
+	b.mtx.Lock()
+	prev := b.status.State
+	b.status.State = state
+	b.mtx.Unlock()
+
+	if state == prev {
+		return false
+	}
+	b.logger.Log("state", state, "prev", prev)
+	return state == STATE_BUS_OFF
+}
+
+// dispatch handles a line received from the SLCAN device, including its
+// terminator
+func (b *Backend) dispatch(l []byte) {
+	b.handle(parseLine(l))
+}
+
+// handle answers outstanding commands, and stores frames and error events
+// received from the SLCAN device
+func (b *Backend) handle(l rxLine) {
+	switch l.kind {
+	case rxNack:
+		b.resolve(ErrBackendNack)
+	case rxAck:
+		b.resolve(nil)
+	case rxReply:
+		b.resolveReply(l.reply, nil)
+	case rxError:
+		l.event.ReceivedAt = time.Now()
+		b.errlog.add(l.event)
+		if l.event.State != "" && b.updateState(l.event.State) && b.recover {
+			b.busOff = true
+		}
+	case rxFrame:
+		// Remote requests from other nodes carry no data worth storing
+		m := l.msg
+		if !m.RTR && b.accept(m) {
+			m.ReceivedAt, m.Direction = time.Now(), DIRECTION_RX
+			if m.Timestamp != nil {
+				m.ReceivedAt = b.clock.time(*m.Timestamp, m.ReceivedAt)
+			}
+			_ = b.db.WriteData(m)
+			b.notify(m)
+		}
+	}
+}
+
+// RemoteRequest transmits a remote frame and, when wait is non-zero,
+// waits for the data frame answering it.
+func (b *Backend) RemoteRequest(id int, dlc int, wait time.Duration) (Message, error) {
+	return b.remoteRequest(b.PostMessage, id, dlc, wait)
+}
+
+// remoteRequest transmits a remote frame through post and waits for the
+// response, shared by the backends which only differ in how they transmit
+func (b *Backend) remoteRequest(post func(Message) error, id int, dlc int, wait time.Duration) (Message, error) {
+	var rx chan Message
+	if wait > 0 {
+		// Subscribe before transmitting so a fast response is not missed
+		rx = b.subscribe(uint32(id))
+		defer b.unsubscribe(uint32(id), rx)
+	}
+	if err := post(Message{ID: uint32(id), RTR: true, DLC: dlc}); err != nil {
+		return Message{}, err
+	}
+	if wait == 0 {
+		return Message{}, nil
+	}
+	select {
+	case m := <-rx:
+		return m, nil
+	case <-time.After(wait):
+		return Message{}, ErrBackendTimeout
+	}
+}
+
+func (b *Backend) subscribe(id uint32) chan Message {
+	rx := make(chan Message, 1)
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.waiters[id] = append(b.waiters[id], rx)
+	return rx
+}
+
+func (b *Backend) unsubscribe(id uint32, rx chan Message) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	w := b.waiters[id]
+	for i := range w {
+		if w[i] == rx {
+			w = append(w[:i], w[i+1:]...)
+			break
+		}
+	}
+	if len(w) == 0 {
+		delete(b.waiters, id)
+	} else {
+		b.waiters[id] = w
+	}
+}
+
+// notify hands a received data frame to requests waiting for it
+func (b *Backend) notify(m Message) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	for _, rx := range b.waiters[m.ID] {
+		select {
+		case rx <- m:
+		default:
+		}
+	}
+}
+
+func (b *Backend) PostMessage(m Message) error {
+	if b.getConfig().listenOnly {
+		return ErrBackendListenOnly
+	}
+	// The hold is not kept while waiting for Handler, which may be about
+	// to take it, but checked again whenever Handler is busy for a tick
+	done := make(chan error, 1)
+	for {
+		if !b.hold.TryLock() {
+			return b.unavailable()
+		}
+		b.hold.Unlock()
+		select {
+		case b.ch <- txRequest{m: m, done: done}:
+			return <-done
+		case <-time.After(slcanTick):
+		}
+	}
+}
+
 func (b *Backend) Reboot() error {
 	if !b.hold.TryLock() {
-		return ErrBackendOnhold
+		return b.unavailable()
 	}
 	defer b.hold.Unlock()
 	b.rst <- true
@@ -178,35 +983,382 @@ func (b *Backend) Reboot() error {
 }
 
 func (b *Backend) Unlock() error {
+	// Only a rebooted device is waiting for its firmware update, a request
+	// already pending makes this one a no-op
+	if st, _ := b.GetStatus(); st.Link == LINK_REBOOTING {
+		select {
+		case b.init <- true:
+		default:
+		}
+	}
+
+	return nil
+}
+
+func (b *Backend) GetBitrate() (Bitrate, error) {
+	return b.getConfig().bitrate, nil
+}
+
+func (b *Backend) SetBitrate(br Bitrate) error {
+	if _, err := bitrateSeq(br); err != nil {
+		return err
+	}
+	c := b.getConfig()
+	c.bitrate = br
+	return b.reconfigure(c)
+}
+
+func (b *Backend) GetAcceptance() (Acceptance, error) {
+	return b.getConfig().acceptance, nil
+}
+
+func (b *Backend) SetAcceptance(a Acceptance) error {
+	if _, err := acceptanceSeq(a); err != nil {
+		return err
+	}
+	c := b.getConfig()
+	c.acceptance = a
+	return b.reconfigure(c)
+}
+
+func (b *Backend) GetFilters() ([]Filter, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return append([]Filter{}, b.filters...), nil
+}
+
+// SetFilters replaces the software filters applied to received frames,
+// an empty list accepts every frame
+func (b *Backend) SetFilters(f []Filter) error {
+	for _, i := range f {
+		if err := i.validate(); err != nil {
+			return err
+		}
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.filters = append([]Filter{}, f...)
+	return nil
+}
+
+func (b *Backend) SetMode(mode string) error {
+	c := b.getConfig()
+	switch mode {
+	case MODE_NORMAL:
+		c.listenOnly = false
+	case MODE_LISTEN_ONLY:
+		c.listenOnly = true
+	default:
+		return ErrBackendInvalidMode
+	}
+	return b.reconfigure(c)
+}
+
+func (b *Backend) GetStatus() (Status, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	st := b.status
+	st.Mode = MODE_NORMAL
+	if b.conf.listenOnly {
+		st.Mode = MODE_LISTEN_ONLY
+	}
+	return st, nil
+}
+
+func (b *Backend) GetDeviceInfo() (DeviceInfo, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.device, nil
+}
+
+// QueryDeviceInfo has Handler query the SLCAN device again
+func (b *Backend) QueryDeviceInfo() (DeviceInfo, error) {
 	if !b.hold.TryLock() {
-		b.init <- true
+		return DeviceInfo{}, b.unavailable()
 	}
+	done := make(chan error, 1)
+	b.qry <- done
+	b.hold.Unlock()
+	if err := <-done; err != nil {
+		return DeviceInfo{}, err
+	}
+	return b.GetDeviceInfo()
+}
 
+func (b *Backend) GetErrors() (ErrorReport, error) {
+	return b.errlog.report(), nil
+}
+
+func (b *Backend) ClearErrors() error {
+	b.errlog.clear()
 	return nil
 }
 
+func (b *Backend) GetJobs() ([]Job, error) {
+	return b.sched.list(), nil
+}
+
+func (b *Backend) GetJob(id int) (Job, error) {
+	return b.sched.get(id)
+}
+
+// AddJob schedules a cyclic transmission, started right away
+func (b *Backend) AddJob(j Job) (Job, error) {
+	if _, err := encapsSlcanFrame(j.Message); err != nil {
+		return Job{}, err
+	}
+	return b.sched.add(j)
+}
+
+func (b *Backend) UpdateJob(id int, j Job) (Job, error) {
+	if _, err := encapsSlcanFrame(j.Message); err != nil {
+		return Job{}, err
+	}
+	return b.sched.update(id, j)
+}
+
+func (b *Backend) DeleteJob(id int) error {
+	return b.sched.remove(id)
+}
+
+func (b *Backend) StartJob(id int) (Job, error) {
+	return b.sched.resume(id)
+}
+
+func (b *Backend) StopJob(id int) (Job, error) {
+	return b.sched.suspend(id)
+}
+
+// reconfigure has Handler close the SLCAN channel, apply the configuration
+// and reopen the channel
+func (b *Backend) reconfigure(c slcanConfig) error {
+	if !b.hold.TryLock() {
+		return b.unavailable()
+	}
+	done := make(chan error, 1)
+	b.cfg <- configRequest{conf: c, done: done}
+	b.hold.Unlock()
+	return <-done
+}
+
+// unavailable explains why frontend requests are held off
+func (b *Backend) unavailable() error {
+	switch st, _ := b.GetStatus(); st.Link {
+	case LINK_RECONNECTING:
+		return ErrBackendReconnecting
+	case LINK_CLOSED:
+		return ErrBackendClosed
+	}
+	return ErrBackendOnhold
+}
+
+func (b *Backend) setLink(state string) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.status.Link = state
+}
+
+// swapLink moves the link from one state to another, telling whether it
+// was in the former
+func (b *Backend) swapLink(from, to string) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.status.Link != from {
+		return false
+	}
+	b.status.Link = to
+	return true
+}
+
+func (b *Backend) getConfig() slcanConfig {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.conf
+}
+
+// accept applies the software filters to a received frame
+func (b *Backend) accept(m Message) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return acceptFrame(b.filters, m)
+}
+
+// openSeq returns the commands which close the SLCAN channel, apply the
+// configuration and open the channel again
+func openSeq(c slcanConfig) ([][]byte, error) {
+	seq := [][]byte{[]byte("C\r")}
+	if c.bitrate != (Bitrate{}) {
+		sl, err := bitrateSeq(c.bitrate)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, sl...)
+	}
+	if c.acceptance != (Acceptance{}) {
+		sl, err := acceptanceSeq(c.acceptance)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, sl...)
+	}
+	if c.tstamp {
+		seq = append(seq, []byte("Z1\r"))
+	}
+	if c.listenOnly {
+		return append(seq, []byte("L\r")), nil
+	}
+	return append(seq, []byte("O\r")), nil
+}
+
+// bitrateSeq returns the commands which apply the nominal bitrate and the
+// CAN FD data phase bitrate, whichever are given
+func bitrateSeq(br Bitrate) ([][]byte, error) {
+	var seq [][]byte
+	if br.Rate != 0 || br.BTR != "" {
+		sl, err := encapsSlcanBitrate(br)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, sl)
+	}
+	if br.Data != 0 {
+		sl, err := encapsSlcanDataBitrate(br.Data)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, sl)
+	}
+	if len(seq) == 0 {
+		return nil, ErrBackendInvalidRate
+	}
+	return seq, nil
+}
+
+// ParseBitrate parses a bitrate given either in bit/s with an optional
+// k or M suffix (e.g. "500k"), or as BTR register values (e.g. "btr:031c").
+func ParseBitrate(s string) (Bitrate, error) {
+	if strings.HasPrefix(s, "btr:") {
+		br := Bitrate{BTR: strings.TrimPrefix(s, "btr:")}
+		if _, err := encapsSlcanBitrate(br); err != nil {
+			return Bitrate{}, err
+		}
+		return br, nil
+	}
+
+	r, err := parseRate(s)
+	if err != nil {
+		return Bitrate{}, err
+	}
+	br := Bitrate{Rate: r}
+	if _, err := encapsSlcanBitrate(br); err != nil {
+		return Bitrate{}, err
+	}
+	return br, nil
+}
+
+// ParseDataBitrate parses a CAN FD data phase bitrate given in bit/s with
+// an optional k or M suffix (e.g. "2M").
+func ParseDataBitrate(s string) (int, error) {
+	r, err := parseRate(s)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := encapsSlcanDataBitrate(r); err != nil {
+		return 0, err
+	}
+	return r, nil
+}
+
+func parseRate(s string) (int, error) {
+	mul := 1
+	if strings.HasSuffix(s, "k") {
+		mul, s = 1000, strings.TrimSuffix(s, "k")
+	} else if strings.HasSuffix(s, "M") {
+		mul, s = 1000000, strings.TrimSuffix(s, "M")
+	}
+	r, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, ErrBackendInvalidRate
+	}
+	return r * mul, nil
+}
+
+func encapsSlcanBitrate(br Bitrate) ([]byte, error) {
+	if br.BTR != "" {
+		if br.Rate != 0 || len(br.BTR) != 4 {
+			return nil, ErrBackendInvalidRate
+		}
+		if _, err := hex.DecodeString(br.BTR); err != nil {
+			return nil, ErrBackendInvalidRate
+		}
+		return []byte("s" + br.BTR + "\r"), nil
+	}
+
+	n, ok := slcanBitrates[br.Rate]
+	if !ok {
+		return nil, ErrBackendInvalidRate
+	}
+	return []byte{'S', n, '\r'}, nil
+}
+
+func encapsSlcanDataBitrate(r int) ([]byte, error) {
+	n, ok := slcanDataBitrates[r]
+	if !ok {
+		return nil, ErrBackendInvalidRate
+	}
+	return []byte{'Y', n, '\r'}, nil
+}
+
 func encapsSlcanFrame(m Message) ([]byte, error) {
 	var s string
 
-	// Determine slcan data frame prefix
+	// Determine slcan data, remote or CAN FD frame prefix
+	if m.BRS && !m.FD {
+		return nil, ErrBackendInvalidFrame
+	}
+	t, T := "t", "T"
+	if m.RTR {
+		// CAN FD has no remote frames
+		if m.FD {
+			return nil, ErrBackendInvalidFrame
+		}
+		t, T = "r", "R"
+	} else if m.BRS {
+		t, T = "b", "B"
+	} else if m.FD {
+		t, T = "d", "D"
+	}
+
 	// Append slcan filter ID
-	if m.ID <= 0x7ff {
-		s += fmt.Sprintf("t%03x", m.ID)
+	if m.ID <= 0x7ff && !m.Extended {
+		s += fmt.Sprintf("%s%03x", t, m.ID)
 	} else if m.ID <= 0x1fffffff {
-		s += fmt.Sprintf("T%08x", m.ID)
+		s += fmt.Sprintf("%s%08x", T, m.ID)
 	} else {
 		return nil, ErrBackendInvalidID
 	}
 
+	// Remote frames carry the requested dlc but no data
+	if m.RTR {
+		if m.DLC < 0 || m.DLC > 8 || len(m.Data) != 0 {
+			return nil, ErrBackendInvalidData
+		}
+		s += fmt.Sprintf("%1x\r\x00", m.DLC)
+		return []byte(s), nil
+	}
+
 	// Determine and append slcan frame dlc
 	dlc := len(m.Data)
-	if dlc > 8 {
+	if m.FD {
+		dlc = slcanFDDlc(dlc)
+	}
+	if dlc < 0 || (dlc > 8 && !m.FD) {
 		return nil, ErrBackendInvalidData
 	}
 
 	// Append slcan frame dlc, data, and terminators
 	s += fmt.Sprintf("%1x", dlc)
-	s += fmt.Sprintf("%x", []byte(m.Data))
+	s += fmt.Sprintf("%x", m.Data)
 	s += "\r\x00"
 
 	return []byte(s), nil
@@ -214,46 +1366,151 @@ func encapsSlcanFrame(m Message) ([]byte, error) {
 
 func decapsSlcanFrame(f []byte) (Message, error) {
 	var m Message
-	var dlc, p int
-
-	if f[0] == 't' {
-		id, err := strconv.ParseInt(string(f[1:4]), 16, 32)
-		if err != nil || id >= 0x800 {
-			return Message{}, ErrBackendInvalidID
-		}
-		m.ID = uint32(id)
-		dlc = int(f[4] - '0')
-		p = 5
-	} else if f[0] == 'T' {
-		id, err := strconv.ParseInt(string(f[1:9]), 16, 32)
-		if err != nil || id >= 0x20000000 {
-			return Message{}, ErrBackendInvalidID
-		}
-		m.ID = uint32(id)
-		dlc = int(f[9] - '0')
-		p = 10
-	} else {
+	var n int
+
+	// Determine ID length from slcan frame prefix
+	switch f[0] {
+	case 't', 'r', 'd', 'b':
+		n = 3
+	case 'T', 'R', 'D', 'B':
+		n = 8
+	default:
+		return Message{}, ErrBackendInvalidFrame
+	}
+	m.RTR = f[0] == 'r' || f[0] == 'R'
+	m.FD = f[0] == 'd' || f[0] == 'D' || f[0] == 'b' || f[0] == 'B'
+	m.BRS = f[0] == 'b' || f[0] == 'B'
+	m.Extended = n == 8
 
+	// Prefix, ID, dlc and terminator at least
+	if len(f) < n+3 {
+		return Message{}, ErrBackendInvalidFrame
+	}
+
+	id, err := strconv.ParseUint(string(f[1:1+n]), 16, 32)
+	if err != nil || (n == 3 && id >= 0x800) || id >= 0x20000000 {
+		return Message{}, ErrBackendInvalidID
 	}
+	m.ID = uint32(id)
 
-	if dlc > 8 {
+	dlc, err := strconv.ParseUint(string(f[1+n:2+n]), 16, 8)
+	if err != nil || (dlc > 8 && !m.FD) {
 		return Message{}, ErrBackendInvalidData
 	}
+	p := 2 + n
 
-	if f[p+dlc*2] != byte('\r') {
+	// Remote frames carry the requested dlc but no data
+	l := int(dlc)
+	if m.RTR {
+		m.DLC, l = int(dlc), 0
+	} else if m.FD {
+		l = slcanFDLengths[dlc]
+	}
+	if len(f) <= p+l*2 {
 		return Message{}, ErrBackendInvalidFrame
 	}
 
-	f = f[p : p+dlc*2]
-	d := make([]byte, dlc)
+	// Data is optionally followed by a 4 digit timestamp
+	if f[p+l*2] != byte('\r') {
+		q := p + l*2
+		if len(f) <= q+4 || f[q+4] != byte('\r') {
+			return Message{}, ErrBackendInvalidFrame
+		}
+		ts, err := strconv.ParseUint(string(f[q:q+4]), 16, 16)
+		if err != nil || ts >= slcanTimestampWrap {
+			return Message{}, ErrBackendInvalidFrame
+		}
+		t := uint16(ts)
+		m.Timestamp = &t
+	}
+	if m.RTR {
+		return m, nil
+	}
+
+	f = f[p : p+l*2]
+	d := make([]byte, l)
 	if _, err := hex.Decode(d, f); err != nil {
 		return Message{}, ErrBackendInvalidFrame
 	}
-	m.Data = string(d)
+	m.Data = d
 
 	return m, nil
 }
 
+// decodeStatusFlags decodes the two hex digits of an "F" query reply
+func decodeStatusFlags(r string) (StatusFlags, error) {
+	if len(r) != 2 {
+		return StatusFlags{}, ErrBackendInvalidFrame
+	}
+	v, err := strconv.ParseUint(r, 16, 8)
+	if err != nil {
+		return StatusFlags{}, ErrBackendInvalidFrame
+	}
+	return StatusFlags{
+		RxFifoFull:      v&0x01 != 0,
+		TxFifoFull:      v&0x02 != 0,
+		ErrorWarning:    v&0x04 != 0,
+		DataOverrun:     v&0x08 != 0,
+		ErrorPassive:    v&0x20 != 0,
+		ArbitrationLost: v&0x40 != 0,
+		BusError:        v&0x80 != 0,
+	}, nil
+}
+
+// parseSlcanVersion splits a "V" query reply into hardware and software
+// versions, two digits each
+func parseSlcanVersion(r string) (string, string) {
+	if len(r) != 4 {
+		return "", r
+	}
+	return r[:2], r[2:]
+}
+
+// slcanTimestampWrap is the period in milliseconds after which SLCAN device
+// timestamps wrap around to zero
+const slcanTimestampWrap = 60000
+
+// timestampClock converts SLCAN device timestamps into monotonic host time,
+// anchored to the host time the first timestamp was received at
+type timestampClock struct {
+	base    time.Time
+	last    uint16
+	elapsed time.Duration
+}
+
+func (c *timestampClock) time(ts uint16, now time.Time) time.Time {
+	if c.base.IsZero() {
+		c.base, c.last = now, ts
+		return now
+	}
+
+	// Count timestamp rollovers since the previous frame
+	delta := (int(ts) - int(c.last) + slcanTimestampWrap) % slcanTimestampWrap
+	c.elapsed += time.Duration(delta) * time.Millisecond
+	c.last = ts
+
+	// Bus was quiet for longer than a wrap period, add the rollovers missed
+	period := slcanTimestampWrap * time.Millisecond
+	for now.Sub(c.base.Add(c.elapsed)) > period/2 {
+		c.elapsed += period
+	}
+	return c.base.Add(c.elapsed)
+}
+
+// slcanFDLengths maps CAN FD dlc to payload length
+var slcanFDLengths = [16]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 12, 16, 20, 24, 32, 48, 64}
+
+// slcanFDDlc returns the CAN FD dlc for a payload length, or -1 if no dlc
+// encodes that length
+func slcanFDDlc(l int) int {
+	for dlc, n := range slcanFDLengths {
+		if n == l {
+			return dlc
+		}
+	}
+	return -1
+}
+
 func msgQueuePing(url string) error {
 	conn, err := amqp.Dial(url)
 	if err != nil {
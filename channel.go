@@ -0,0 +1,58 @@
+package slcansvc
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var (
+	ErrChannelInvalidName = errors.New("Channel: invalid name")
+	ErrChannelInvalidPort = errors.New("Channel: invalid port")
+	ErrChannelDuplicate   = errors.New("Channel: duplicate name")
+)
+
+// DEFAULT_CHANNEL names a channel given without a name
+const DEFAULT_CHANNEL = "default"
+
+// Channel is a SLCAN adapter served under /slcan/{Name}
+type Channel struct {
+	Name string
+	Port string
+}
+
+// channelName starts with a letter, so channel routes never collide with
+// CAN IDs
+var channelName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// reservedChannels collide with the routes served under /slcan
+var reservedChannels = map[string]bool{
+	"config":  true,
+	"device":  true,
+	"docs":    true,
+	"errors":  true,
+	"history": true,
+	"jobs":    true,
+	"reboot":  true,
+	"rx":      true,
+	"status":  true,
+	"tx":      true,
+	"unlock":  true,
+}
+
+// ParseChannel parses a channel given as name=port, e.g.
+// powertrain=/dev/ttyACM0, or as a port only, which is named
+// DEFAULT_CHANNEL.
+func ParseChannel(s string) (Channel, error) {
+	c := Channel{Name: DEFAULT_CHANNEL, Port: s}
+	if name, port, ok := strings.Cut(s, "="); ok {
+		c = Channel{Name: name, Port: port}
+	}
+	if !channelName.MatchString(c.Name) || reservedChannels[c.Name] {
+		return Channel{}, ErrChannelInvalidName
+	}
+	if c.Port == "" {
+		return Channel{}, ErrChannelInvalidPort
+	}
+	return c, nil
+}
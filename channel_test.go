@@ -0,0 +1,25 @@
+package slcansvc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChannel(t *testing.T) {
+	c, err := ParseChannel("powertrain=/dev/ttyACM0")
+	assert.Equal(t, Channel{Name: "powertrain", Port: "/dev/ttyACM0"}, c)
+	assert.Equal(t, nil, err)
+
+	c, err = ParseChannel("tcp://192.168.1.10:3333")
+	assert.Equal(t, Channel{Name: DEFAULT_CHANNEL, Port: "tcp://192.168.1.10:3333"}, c)
+	assert.Equal(t, nil, err)
+
+	// names must not collide with CAN IDs or other routes
+	for _, s := range []string{"123=/dev/ttyACM0", "=/dev/ttyACM0", "a/b=/dev/ttyACM0", "status=/dev/ttyACM0"} {
+		_, err = ParseChannel(s)
+		assert.Equal(t, ErrChannelInvalidName, err, s)
+	}
+	_, err = ParseChannel("body=")
+	assert.Equal(t, ErrChannelInvalidPort, err)
+}
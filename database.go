@@ -1,75 +1,473 @@
 package slcansvc
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 var (
 	ErrDatabaseAlreadyExists = errors.New("Database: already exists")
 	ErrDatabaseNotFound      = errors.New("Database: request not found")
+	ErrDatabaseInvalidData   = errors.New("Database: invalid data encoding")
+)
+
+const (
+	DIRECTION_TX = "tx"
+	DIRECTION_RX = "rx"
+)
+
+// Encodings of message data in JSON
+const (
+	ENCODING_HEX    = "hex"
+	ENCODING_BASE64 = "base64"
+	ENCODING_ARRAY  = "array"
 )
 
 type Message struct {
-	ID   uint32 `json:"id" example:"123"`
-	Data string `json:"data" example:"200rpm"`
+	ID uint32 `json:"id" example:"123"`
+	// Data is the frame payload, encoded in JSON as a hex string by
+	// default, a base64 string or an array of bytes as negotiated
+	Data []byte `json:"data" swaggertype:"string" format:"hex" example:"32303072706d"`
+	// Extended marks a frame with a 29 bit ID, implied by IDs above 0x7ff
+	Extended bool `json:"extended,omitempty" example:"false"`
+	// RTR marks a remote transmission request
+	RTR bool `json:"rtr,omitempty" example:"false"`
+	// DLC is the data length requested by a remote transmission request
+	DLC int `json:"dlc,omitempty" example:"0"`
+	// FD marks a CAN FD frame carrying up to 64 bytes of data
+	FD bool `json:"fd,omitempty" example:"false"`
+	// BRS switches a CAN FD frame to the data phase bitrate
+	BRS bool `json:"brs,omitempty" example:"false"`
+	// Timestamp is the SLCAN device reception time in milliseconds
+	Timestamp *uint16 `json:"timestamp,omitempty" example:"1234"`
+	// ReceivedAt is the host reception time
+	ReceivedAt time.Time `json:"received_at" example:"2023-06-01T12:00:00Z"`
+	// Direction tells messages configured for transmission from frames
+	// received, set by the database
+	Direction string `json:"direction,omitempty" enums:"tx,rx" example:"rx" readonly:"true"`
+
+	// encoding of Data in JSON, hex when empty
+	encoding string
+}
+
+// ValidEncoding tells whether enc is an encoding of message data
+func ValidEncoding(enc string) bool {
+	switch enc {
+	case ENCODING_HEX, ENCODING_BASE64, ENCODING_ARRAY:
+		return true
+	}
+	return false
+}
+
+// MarshalJSON writes data in the encoding of m, hex when not set
+func (m Message) MarshalJSON() ([]byte, error) {
+	type message Message
+	// ID shadowed to keep data second
+	v := struct {
+		ID   uint32      `json:"id"`
+		Data interface{} `json:"data"`
+		message
+	}{ID: m.ID, message: message(m)}
+	switch m.encoding {
+	case ENCODING_BASE64:
+		v.Data = base64.StdEncoding.EncodeToString(m.Data)
+	case ENCODING_ARRAY:
+		a := make([]int, len(m.Data))
+		for i, b := range m.Data {
+			a[i] = int(b)
+		}
+		v.Data = a
+	default:
+		v.Data = hex.EncodeToString(m.Data)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON reads data as an array of bytes, or a string in the
+// encoding of m, hex when not set
+func (m *Message) UnmarshalJSON(b []byte) error {
+	type message Message
+	v := struct {
+		*message
+		Data json.RawMessage `json:"data"`
+	}{message: (*message)(m)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	m.Data = nil
+	if len(v.Data) == 0 || string(v.Data) == "null" {
+		return nil
+	}
+	var d []byte
+	var err error
+	if v.Data[0] == '[' {
+		// Numbers, not base64 as json would have for bytes
+		err = json.Unmarshal(v.Data, &d)
+	} else {
+		var s string
+		if err = json.Unmarshal(v.Data, &s); err == nil && m.encoding == ENCODING_BASE64 {
+			d, err = base64.StdEncoding.DecodeString(s)
+		} else if err == nil {
+			d, err = hex.DecodeString(s)
+		}
+	}
+	if err != nil {
+		return ErrDatabaseInvalidData
+	}
+	if len(d) > 0 {
+		m.Data = d
+	}
+	return nil
+}
+
+// Database keeps two separate tables: the TX table of messages configured
+// for transmission, posted, updated and removed by frontend requests, and
+// the RX cache of the latest frame received for every CAN ID, written by
+// the backend along with a history of the frames received.
+type Database interface {
+	GetTx(id uint32) (Message, error)
+	PostTx(m Message) error
+	PutTx(id uint32, m Message) error
+	DeleteTx(id uint32) error
+	QueryTx(q MessageQuery) MessageList
+	WriteData(m Message) error
+	GetRx(id uint32) (Message, error)
+	DeleteRx(id uint32) error
+	QueryRx(q MessageQuery) MessageList
+	GetHistory(id uint32, since time.Time, limit int) ([]Message, error)
+	GetHistoryStats() HistoryStats
+	Close() error
+}
+
+// MemoryDatabase is a Database held in memory, lost on restart.
+type MemoryDatabase struct {
+	mtx     sync.Mutex
+	tx      map[uint32]Message
+	rx      map[uint32]Message
+	history *history
+}
+
+// DatabaseOption sets an optional parameter for databases.
+type DatabaseOption func(*MemoryDatabase)
+
+// WithHistorySize sets how many received frames are kept per CAN ID, zero
+// disables the history. Defaults to DefaultHistorySize.
+func WithHistorySize(n int) DatabaseOption {
+	return func(d *MemoryDatabase) { d.history.size = n }
+}
+
+// WithHistoryAge sets how long received frames are kept, zero keeps them
+// until evicted otherwise.
+func WithHistoryAge(age time.Duration) DatabaseOption {
+	return func(d *MemoryDatabase) { d.history.age = age }
+}
+
+// WithHistoryMemory limits the estimated memory used by the frames kept
+// for all CAN IDs, the oldest frames are evicted first. Zero is unlimited.
+// Defaults to DefaultHistoryMemory.
+func WithHistoryMemory(bytes int) DatabaseOption {
+	return func(d *MemoryDatabase) { d.history.limit = bytes }
 }
 
-type Database struct {
-	mtx sync.Mutex
-	db  map[uint32]Message
+// NewDatabase returns an empty in-memory database, one per channel.
+func NewDatabase(options ...DatabaseOption) Database {
+	return newMemoryDatabase(options...)
 }
 
-func (d *Database) GetData(id uint32) (Message, error) {
+func newMemoryDatabase(options ...DatabaseOption) *MemoryDatabase {
+	d := &MemoryDatabase{
+		tx:      map[uint32]Message{},
+		rx:      map[uint32]Message{},
+		history: newHistory(DefaultHistorySize, 0, DefaultHistoryMemory),
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+func (d *MemoryDatabase) GetTx(id uint32) (Message, error) {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
-	m, ok := d.db[id]
+	m, ok := d.tx[id]
 	if !ok {
 		return Message{}, ErrDatabaseNotFound
 	}
 	return m, nil
 }
 
-func (d *Database) PostData(m Message) error {
+// PostTx adds a message to the TX table, frames received for the same CAN
+// ID do not count.
+func (d *MemoryDatabase) PostTx(m Message) error {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
-	_, ok := d.db[m.ID]
+	_, ok := d.tx[m.ID]
 	if ok {
 		return ErrDatabaseAlreadyExists
 	}
-	d.db[m.ID] = m
+	m.Direction = DIRECTION_TX
+	d.tx[m.ID] = m
 	return nil
 }
 
-func (d *Database) PutData(id uint32, m Message) error {
+func (d *MemoryDatabase) PutTx(id uint32, m Message) error {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
-	_, ok := d.db[id]
+	_, ok := d.tx[id]
 	if !ok {
 		return ErrDatabaseNotFound
 	}
-	d.db[id] = m
+	m.Direction = DIRECTION_TX
+	d.tx[id] = m
 	return nil
 }
 
-func (d *Database) DeleteData(id uint32) error {
+func (d *MemoryDatabase) DeleteTx(id uint32) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	_, ok := d.tx[id]
+	if !ok {
+		return ErrDatabaseNotFound
+	}
+	delete(d.tx, id)
+	return nil
+}
+
+func (d *MemoryDatabase) QueryTx(q MessageQuery) MessageList {
+	return d.query(d.tx, q)
+}
+
+// WriteData stores a received frame as the latest message of its CAN ID
+// in the RX cache, and adds it to the history.
+func (d *MemoryDatabase) WriteData(m Message) error {
+	d.write(m, time.Now())
+	return nil
+}
+
+func (d *MemoryDatabase) write(m Message, at time.Time) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	m.Direction = DIRECTION_RX
+	d.rx[m.ID] = m
+	d.history.add(m, at)
+}
+
+func (d *MemoryDatabase) GetRx(id uint32) (Message, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	m, ok := d.rx[id]
+	if !ok {
+		return Message{}, ErrDatabaseNotFound
+	}
+	return m, nil
+}
+
+// DeleteRx drops the latest frame received for a CAN ID along with its
+// history, until received again.
+func (d *MemoryDatabase) DeleteRx(id uint32) error {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
-	_, ok := d.db[id]
+	_, ok := d.rx[id]
 	if !ok {
 		return ErrDatabaseNotFound
 	}
-	delete(d.db, id)
+	delete(d.rx, id)
+	d.history.remove(id)
 	return nil
 }
 
-func (d *Database) WriteData(m Message) error {
+func (d *MemoryDatabase) QueryRx(q MessageQuery) MessageList {
+	return d.query(d.rx, q)
+}
+
+// GetHistory returns the frames received for a CAN ID, oldest first. Given
+// since, the first limit frames received after since are returned,
+// otherwise the last limit frames. Zero limit returns every frame kept.
+func (d *MemoryDatabase) GetHistory(id uint32, since time.Time, limit int) ([]Message, error) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	l, ok := d.history.query(id, since, limit, time.Now())
+	if !ok {
+		if _, ok := d.rx[id]; !ok {
+			return nil, ErrDatabaseNotFound
+		}
+		return []Message{}, nil
+	}
+	return l, nil
+}
+
+func (d *MemoryDatabase) GetHistoryStats() HistoryStats {
 	d.mtx.Lock()
 	defer d.mtx.Unlock()
-	d.db[m.ID] = m
+	return d.history.report(time.Now())
+}
+
+func (d *MemoryDatabase) Close() error {
 	return nil
 }
 
-var db = &Database{
-	db: map[uint32]Message{},
+// snapshot returns the TX table, the RX cache and the frames in history,
+// in the order received
+func (d *MemoryDatabase) snapshot() (map[uint32]Message, map[uint32]Message, []historyEntry) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	tx := make(map[uint32]Message, len(d.tx))
+	for id, m := range d.tx {
+		tx[id] = m
+	}
+	rx := make(map[uint32]Message, len(d.rx))
+	for id, m := range d.rx {
+		rx[id] = m
+	}
+	return tx, rx, d.history.entries()
+}
+
+// setTx, removeTx, cacheRx and removeRx restore the changes once validated
+func (d *MemoryDatabase) setTx(id uint32, m Message) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	m.Direction = DIRECTION_TX
+	d.tx[id] = m
+}
+
+func (d *MemoryDatabase) removeTx(id uint32) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	delete(d.tx, id)
+}
+
+// cacheRx restores the latest frame of a CAN ID, leaving the history alone
+func (d *MemoryDatabase) cacheRx(m Message) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	m.Direction = DIRECTION_RX
+	d.rx[m.ID] = m
+}
+
+func (d *MemoryDatabase) removeRx(id uint32) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	delete(d.rx, id)
+	d.history.remove(id)
+}
+
+// size is the number of messages and frames held
+func (d *MemoryDatabase) size() int {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return len(d.tx) + len(d.rx) + d.history.stats.Entries
+}
+
+const (
+	SORT_ID          = "id"
+	SORT_RECEIVED_AT = "received_at"
+)
+
+// DefaultListLimit and MaxListLimit are the default and maximum number of
+// messages listed at once.
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+// MessageQuery selects stored messages whose ID lies within From and To,
+// a zero To leaving the range unbounded, of Type FILTER_STANDARD or
+// FILTER_EXTENDED, received after Since, whose data contains Data and
+// equals Match in the bits set in Mask. Sort is SORT_ID or
+// SORT_RECEIVED_AT, descending when prefixed with '-'. Limit messages are
+// returned from Offset on.
+type MessageQuery struct {
+	From   uint32
+	To     uint32
+	Type   string
+	Since  time.Time
+	Data   []byte
+	Mask   []byte
+	Match  []byte
+	Sort   string
+	Offset int
+	Limit  int
+}
+
+// MessageList is a page of the stored messages selected by a query, out
+// of Total messages selected.
+type MessageList struct {
+	Messages []Message `json:"messages"`
+	Total    int       `json:"total" example:"250"`
+	Offset   int       `json:"offset" example:"0"`
+	Limit    int       `json:"limit" example:"100"`
+}
+
+// query lists the messages of table selected by q. Messages are only
+// copied under the lock, sorting and paging happen after releasing it.
+func (d *MemoryDatabase) query(table map[uint32]Message, q MessageQuery) MessageList {
+	d.mtx.Lock()
+	l := make([]Message, 0, len(table))
+	for _, m := range table {
+		if q.match(m) {
+			l = append(l, m)
+		}
+	}
+	d.mtx.Unlock()
+
+	desc := strings.HasPrefix(q.Sort, "-")
+	less := func(i, j int) bool { return l[i].ID < l[j].ID }
+	if strings.TrimPrefix(q.Sort, "-") == SORT_RECEIVED_AT {
+		less = func(i, j int) bool {
+			if l[i].ReceivedAt.Equal(l[j].ReceivedAt) {
+				return l[i].ID < l[j].ID
+			}
+			return l[i].ReceivedAt.Before(l[j].ReceivedAt)
+		}
+	}
+	sort.Slice(l, func(i, j int) bool {
+		if desc {
+			return less(j, i)
+		}
+		return less(i, j)
+	})
+
+	r := MessageList{Total: len(l), Offset: q.Offset, Limit: q.Limit}
+	if q.Offset < len(l) {
+		l = l[q.Offset:]
+	} else {
+		l = l[:0]
+	}
+	if q.Limit > 0 && len(l) > q.Limit {
+		l = l[:q.Limit]
+	}
+	r.Messages = l
+	return r
+}
+
+func (q MessageQuery) match(m Message) bool {
+	if m.ID < q.From || (q.To != 0 && m.ID > q.To) {
+		return false
+	}
+	extended := m.Extended || m.ID > 0x7ff
+	if (q.Type == FILTER_STANDARD && extended) || (q.Type == FILTER_EXTENDED && !extended) {
+		return false
+	}
+	if !q.Since.IsZero() && !m.ReceivedAt.After(q.Since) {
+		return false
+	}
+	if len(q.Data) > 0 && !bytes.Contains(m.Data, q.Data) {
+		return false
+	}
+	if len(q.Mask) > len(m.Data) {
+		return false
+	}
+	for i, b := range q.Mask {
+		if m.Data[i]&b != q.Match[i]&b {
+			return false
+		}
+	}
+	return true
 }
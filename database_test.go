@@ -2,58 +2,114 @@ package slcansvc
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestDatabase(t *testing.T) {
 	var m Message
+	db := NewDatabase()
 
 	// call GetData(), no data found
-	m, err := db.GetData(0x7ff)
+	m, err := db.GetTx(0x7ff)
 	assert.Empty(t, m)
 	assert.NotEqual(t, nil, err)
 
 	// call PostData(), write id:0x7ff succeed
-	m = Message{0x7ff, "200rpm"}
-	err = db.PostData(m)
+	m = Message{ID: 0x7ff, Data: []byte("200rpm")}
+	err = db.PostTx(m)
 	assert.Equal(t, nil, err)
 
 	// call GetData(), read id:0x7ff succeed
-	m, err = db.GetData(0x7ff)
-	assert.Equal(t, m, Message{0x7ff, "200rpm"})
+	m, err = db.GetTx(0x7ff)
+	assert.Equal(t, m, Message{ID: 0x7ff, Data: []byte("200rpm"), Direction: DIRECTION_TX})
 	assert.Equal(t, nil, err)
 
 	// call PostData(), data already exists
-	m = Message{0x7ff, "200rpm"}
-	err = db.PostData(m)
+	m = Message{ID: 0x7ff, Data: []byte("200rpm")}
+	err = db.PostTx(m)
 	assert.NotEqual(t, nil, err)
 
 	// call PutData(), write id:0x7ff succeed
-	m = Message{0x7ff, "201rpm"}
-	err = db.PutData(0x7ff, m)
+	m = Message{ID: 0x7ff, Data: []byte("201rpm")}
+	err = db.PutTx(0x7ff, m)
 	assert.Equal(t, nil, err)
 
 	// call GetData(), read id:0x7ff succeed
-	m, err = db.GetData(0x7ff)
-	assert.Equal(t, m, Message{0x7ff, "201rpm"})
+	m, err = db.GetTx(0x7ff)
+	assert.Equal(t, m, Message{ID: 0x7ff, Data: []byte("201rpm"), Direction: DIRECTION_TX})
 	assert.Equal(t, nil, err)
 
 	// call DeleteData(), delete id:0x7ff succeed
-	err = db.DeleteData(0x7ff)
+	err = db.DeleteTx(0x7ff)
 	assert.Equal(t, nil, err)
 
 	// call GetData(), no data found
-	m, err = db.GetData(0x7ff)
+	m, err = db.GetTx(0x7ff)
 	assert.Empty(t, m)
 	assert.NotEqual(t, nil, err)
 
 	// call PutData(), no data found
-	m = Message{0x7ff, "201rpm"}
-	err = db.PutData(0x7ff, m)
+	m = Message{ID: 0x7ff, Data: []byte("201rpm")}
+	err = db.PutTx(0x7ff, m)
 	assert.NotEqual(t, nil, err)
 
 	// call DeleteData(), no data found
-	err = db.DeleteData(0x7ff)
+	err = db.DeleteTx(0x7ff)
 	assert.NotEqual(t, nil, err)
+
+	// frames received are kept apart from messages posted
+	assert.Equal(t, nil, db.WriteData(Message{ID: 0x7ff, Data: []byte("rx")}))
+	assert.Equal(t, nil, db.PostTx(Message{ID: 0x7ff, Data: []byte("tx")}))
+	assert.Equal(t, nil, db.WriteData(Message{ID: 0x7ff, Data: []byte("rx2")}))
+	m, _ = db.GetTx(0x7ff)
+	assert.Equal(t, Message{ID: 0x7ff, Data: []byte("tx"), Direction: DIRECTION_TX}, m)
+	m, _ = db.GetRx(0x7ff)
+	assert.Equal(t, Message{ID: 0x7ff, Data: []byte("rx2"), Direction: DIRECTION_RX}, m)
+	assert.Equal(t, nil, db.DeleteRx(0x7ff))
+	_, err = db.GetTx(0x7ff)
+	assert.Equal(t, nil, err)
+}
+
+func TestDatabaseQuery(t *testing.T) {
+	d := NewDatabase()
+	t0 := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, m := range []Message{
+		{ID: 0x300, Data: []byte("200rpm")},
+		{ID: 0x100, Data: []byte("\x12\x34")},
+		{ID: 0x12345678, Data: []byte("door open")},
+		{ID: 0x200, Extended: true, Data: []byte("\x12\xff")},
+	} {
+		m.ReceivedAt = t0.Add(time.Duration(3-i) * time.Second)
+		_ = d.WriteData(m)
+	}
+	ids := func(l MessageList) []uint32 {
+		r := []uint32{}
+		for _, m := range l.Messages {
+			r = append(r, m.ID)
+		}
+		return r
+	}
+
+	l := d.QueryRx(MessageQuery{})
+	assert.Equal(t, []uint32{0x100, 0x200, 0x300, 0x12345678}, ids(l))
+	assert.Equal(t, 4, l.Total)
+
+	// sorting and paging
+	assert.Equal(t, []uint32{0x12345678, 0x300}, ids(d.QueryRx(MessageQuery{Sort: "-id", Limit: 2})))
+	assert.Equal(t, []uint32{0x200, 0x12345678}, ids(d.QueryRx(MessageQuery{Sort: SORT_RECEIVED_AT, Limit: 2})))
+	l = d.QueryRx(MessageQuery{Offset: 3, Limit: 2})
+	assert.Equal(t, []uint32{0x12345678}, ids(l))
+	assert.Equal(t, 4, l.Total)
+	assert.Equal(t, []uint32{}, ids(d.QueryRx(MessageQuery{Offset: 5})))
+
+	// filters
+	assert.Equal(t, []uint32{0x200, 0x300}, ids(d.QueryRx(MessageQuery{From: 0x200, To: 0x7ff})))
+	assert.Equal(t, []uint32{0x100, 0x300}, ids(d.QueryRx(MessageQuery{Type: FILTER_STANDARD})))
+	assert.Equal(t, []uint32{0x200, 0x12345678}, ids(d.QueryRx(MessageQuery{Type: FILTER_EXTENDED})))
+	assert.Equal(t, []uint32{0x100, 0x300}, ids(d.QueryRx(MessageQuery{Since: t0.Add(time.Second)})))
+	assert.Equal(t, []uint32{0x12345678}, ids(d.QueryRx(MessageQuery{Data: []byte("open")})))
+	assert.Equal(t, []uint32{0x100, 0x200}, ids(d.QueryRx(MessageQuery{Mask: []byte{0xff, 0}, Match: []byte{0x12, 0}})))
+	assert.Equal(t, []uint32{0x200}, ids(d.QueryRx(MessageQuery{Mask: []byte{0, 0xf0}, Match: []byte{0, 0xf0}})))
 }
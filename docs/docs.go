@@ -20,9 +20,48 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/slcan/": {
+        "/slcan/{channel}/config/acceptance": {
+            "get": {
+                "description": "Retrieve acceptance code and mask currently programmed into SLCAN device",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve acceptance filter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Acceptance"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
             "post": {
-                "description": "Add new CAN message by specifying CAN ID and data",
+                "description": "Program acceptance code and mask, 8 hex digits each, into SLCAN device",
                 "consumes": [
                     "application/json"
                 ],
@@ -32,14 +71,22 @@ const docTemplate = `{
                 "tags": [
                     "SLCAN"
                 ],
-                "summary": "Add new CAN message",
+                "summary": "Configure acceptance filter",
                 "parameters": [
                     {
-                        "description": "CAN Message",
-                        "name": "array",
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Acceptance code and mask",
+                        "name": "acceptance",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.Message"
+                            "$ref": "#/definitions/slcansvc.Acceptance"
                         }
                     }
                 ],
@@ -59,9 +106,48 @@ const docTemplate = `{
                 }
             }
         },
-        "/slcan/reboot": {
+        "/slcan/{channel}/config/bitrate": {
+            "get": {
+                "description": "Retrieve CAN bus bitrate currently applied to SLCAN device",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve CAN bitrate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Bitrate"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
             "post": {
-                "description": "Reboot SLCAN device for firmware update",
+                "description": "Configure CAN bus bitrate by specifying either a standard rate (10000 - 1000000) or BTR register values, and optionally the CAN FD data phase rate (1000000 - 8000000)",
                 "consumes": [
                     "application/json"
                 ],
@@ -71,7 +157,25 @@ const docTemplate = `{
                 "tags": [
                     "SLCAN"
                 ],
-                "summary": "Reboot SLCAN device",
+                "summary": "Configure CAN bitrate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CAN Bitrate",
+                        "name": "bitrate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Bitrate"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "OK"
@@ -88,9 +192,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/slcan/{id}": {
+        "/slcan/{channel}/config/filters": {
             "get": {
-                "description": "Retrieve CAN message by specifying CAN ID",
+                "description": "Retrieve software filters applied to received CAN messages",
                 "consumes": [
                     "application/json"
                 ],
@@ -100,14 +204,12 @@ const docTemplate = `{
                 "tags": [
                     "SLCAN"
                 ],
-                "summary": "Retrieve CAN message",
+                "summary": "Retrieve software filters",
                 "parameters": [
                     {
-                        "maximum": 536870911,
-                        "minimum": 0,
-                        "type": "integer",
-                        "description": "CAN ID",
-                        "name": "int",
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
                         "in": "path",
                         "required": true
                     }
@@ -118,7 +220,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/main.Message"
+                                "$ref": "#/definitions/slcansvc.Filter"
                             }
                         }
                     },
@@ -133,8 +235,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "put": {
-                "description": "Update existing CAN message by specifying CAN ID and data",
+            "post": {
+                "description": "Replace software filters applied to received CAN messages, an empty list accepts every message",
                 "consumes": [
                     "application/json"
                 ],
@@ -144,14 +246,72 @@ const docTemplate = `{
                 "tags": [
                     "SLCAN"
                 ],
-                "summary": "Update existing CAN message",
+                "summary": "Replace software filters",
                 "parameters": [
                     {
-                        "description": "CAN Message",
-                        "name": "array",
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Software filters",
+                        "name": "filters",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/slcansvc.Filter"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/config/mode": {
+            "post": {
+                "description": "Reopen SLCAN channel in either normal or listen-only mode, where the device never acknowledges or transmits frames",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Configure SLCAN mode",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "SLCAN mode",
+                        "name": "mode",
                         "in": "body",
+                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/main.Message"
+                            "$ref": "#/definitions/slcansvc.setModeRequest"
                         }
                     }
                 ],
@@ -169,9 +329,97 @@ const docTemplate = `{
                         "description": "Internal Server Error"
                     }
                 }
+            }
+        },
+        "/slcan/{channel}/device": {
+            "get": {
+                "description": "Retrieve hardware and software versions and serial number of SLCAN device, cached whenever the SLCAN channel is opened unless refresh is requested",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve SLCAN device information",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Query SLCAN device again",
+                        "name": "refresh",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.DeviceInfo"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/errors": {
+            "get": {
+                "description": "Retrieve most recent CAN bus error events reported by SLCAN device, along with counters per error class and controller state",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve CAN bus errors",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.ErrorReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
             },
             "delete": {
-                "description": "Remove CAN message by specifying CAN ID",
+                "description": "Clear CAN bus error events and counters",
                 "consumes": [
                     "application/json"
                 ],
@@ -181,14 +429,12 @@ const docTemplate = `{
                 "tags": [
                     "SLCAN"
                 ],
-                "summary": "Remove CAN message",
+                "summary": "Clear CAN bus errors",
                 "parameters": [
                     {
-                        "maximum": 536870911,
-                        "minimum": 0,
-                        "type": "integer",
-                        "description": "CAN ID",
-                        "name": "int",
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
                         "in": "path",
                         "required": true
                     }
@@ -208,19 +454,1701 @@ const docTemplate = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "main.Message": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "type": "string",
-                    "example": "200rpm"
-                },
-                "id": {
-                    "type": "integer",
-                    "example": 123
+        },
+        "/slcan/{channel}/history": {
+            "get": {
+                "description": "Retrieve number of frames kept in history and estimated memory used, along with frames evicted as the history of their CAN ID was full, as too old, or to stay within the memory limit",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve CAN message history statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.HistoryStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/jobs": {
+            "get": {
+                "description": "Retrieve cyclic transmission jobs along with their state and number of frames transmitted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve cyclic transmission jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/slcansvc.Job"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Add cyclic transmission job, started right away, transmitting CAN message every period milliseconds, count times or until stopped when count is zero. Jobs are paused while SLCAN device is on hold for reboot, and resumed once unlocked.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Add cyclic transmission job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cyclic transmission job, id and state are ignored",
+                        "name": "job",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Job"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/jobs/{id}": {
+            "get": {
+                "description": "Retrieve cyclic transmission job by specifying job ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve cyclic transmission job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "description": "Update CAN message, period and count of cyclic transmission job, restarting it if running",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Update cyclic transmission job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Cyclic transmission job, id and state are ignored",
+                        "name": "job",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Job"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Stop and remove cyclic transmission job by specifying job ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Remove cyclic transmission job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/jobs/{id}/start": {
+            "post": {
+                "description": "Start stopped cyclic transmission job over, counting frames transmitted from zero",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Start cyclic transmission job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/jobs/{id}/stop": {
+            "post": {
+                "description": "Stop cyclic transmission job, keeping it for being started again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Stop cyclic transmission job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/reboot": {
+            "post": {
+                "description": "Reboot SLCAN device for firmware update",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Reboot SLCAN device",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/rx": {
+            "get": {
+                "description": "List latest frame received for every CAN ID seen on the bus, optionally filtered by CAN ID range, frame type, reception time and data, either as a substring or as hex bytes equal to match in the bits set in mask",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "List CAN messages received",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Lowest CAN ID",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Highest CAN ID, unbounded by default",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "std",
+                            "ext"
+                        ],
+                        "type": "string",
+                        "description": "Frame type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Received after, RFC 3339",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data substring, hex bytes",
+                        "name": "data",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data mask, hex bytes",
+                        "name": "mask",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data match, hex bytes as long as mask",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "received_at",
+                            "-received_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, descending when prefixed with -",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Number of messages skipped",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of messages listed, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.MessageList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/rx/{id}": {
+            "get": {
+                "description": "Retrieve latest frame received by specifying CAN ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve CAN message received",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "CAN ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove latest frame received and its history by specifying CAN ID, until received again",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Forget CAN message received",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "CAN ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/rx/{id}/history": {
+            "get": {
+                "description": "Retrieve frames received for CAN ID, oldest first. Given since, the first limit frames received after since are returned, otherwise the last limit frames.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve CAN message history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "CAN ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Reception time, RFC 3339",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Maximum number of frames, all by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/slcansvc.Message"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/status": {
+            "get": {
+                "description": "Retrieve SLCAN channel mode and CAN controller status flags polled from SLCAN device",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve SLCAN status",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Status"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/tx": {
+            "get": {
+                "description": "List CAN messages of the TX table, optionally filtered by CAN ID range, frame type and data, either as a substring or as hex bytes equal to match in the bits set in mask",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "List CAN messages configured for transmission",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Lowest CAN ID",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Highest CAN ID, unbounded by default",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "std",
+                            "ext"
+                        ],
+                        "type": "string",
+                        "description": "Frame type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data substring, hex bytes",
+                        "name": "data",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data mask, hex bytes",
+                        "name": "mask",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data match, hex bytes as long as mask",
+                        "name": "match",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "id",
+                            "-id",
+                            "received_at",
+                            "-received_at"
+                        ],
+                        "type": "string",
+                        "description": "Sort order, descending when prefixed with -",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "Number of messages skipped",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 1000,
+                        "minimum": 1,
+                        "type": "integer",
+                        "description": "Number of messages listed, 100 by default",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.MessageList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "post": {
+                "description": "Add new CAN message to the TX table and transmit it, by specifying CAN ID and data, up to 8 bytes for classic CAN or one of 0-8, 12, 16, 20, 24, 32, 48, 64 bytes for CAN FD. Frames received with the same CAN ID do not conflict.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Add and transmit new CAN message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CAN Message",
+                        "name": "array",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Message"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/tx/{id}": {
+            "get": {
+                "description": "Retrieve CAN message of the TX table by specifying CAN ID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Retrieve CAN message configured for transmission",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "CAN ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "put": {
+                "description": "Update CAN message of the TX table and transmit it, by specifying CAN ID and data",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Update and transmit existing CAN message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "CAN ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "CAN Message",
+                        "name": "array",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Message"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            },
+            "delete": {
+                "description": "Remove CAN message from the TX table by specifying CAN ID, nothing is transmitted",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Remove CAN message configured for transmission",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "CAN ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/unlock": {
+            "post": {
+                "description": "Unlock serial backend from the success of firmware update",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Unlock serial backend",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    }
+                }
+            }
+        },
+        "/slcan/{channel}/{id}/rtr": {
+            "post": {
+                "description": "Transmit remote frame by specifying CAN ID and requested DLC, optionally waiting for the responding data frame",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SLCAN"
+                ],
+                "summary": "Request CAN message",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Channel name",
+                        "name": "channel",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "maximum": 536870911,
+                        "minimum": 0,
+                        "type": "integer",
+                        "description": "CAN ID",
+                        "name": "int",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Remote Request",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.RemoteRequest"
+                        }
+                    },
+                    {
+                        "enum": [
+                            "hex",
+                            "base64",
+                            "array"
+                        ],
+                        "type": "string",
+                        "description": "Message data encoding, hex by default",
+                        "name": "encoding",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/slcansvc.Message"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request"
+                    },
+                    "404": {
+                        "description": "Not Found"
+                    },
+                    "500": {
+                        "description": "Internal Server Error"
+                    },
+                    "504": {
+                        "description": "Gateway Timeout"
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "slcansvc.Acceptance": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string",
+                    "example": "00000000"
+                },
+                "mask": {
+                    "type": "string",
+                    "example": "ffffffff"
+                }
+            }
+        },
+        "slcansvc.Bitrate": {
+            "type": "object",
+            "properties": {
+                "btr": {
+                    "type": "string",
+                    "example": "031c"
+                },
+                "data": {
+                    "type": "integer",
+                    "example": 2000000
+                },
+                "rate": {
+                    "type": "integer",
+                    "example": 500000
+                }
+            }
+        },
+        "slcansvc.DeviceInfo": {
+            "type": "object",
+            "properties": {
+                "hardware": {
+                    "type": "string",
+                    "example": "10"
+                },
+                "serial": {
+                    "type": "string",
+                    "example": "A123"
+                },
+                "software": {
+                    "type": "string",
+                    "example": "13"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "example": "2023-06-01T12:00:00Z"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "3.1.0"
+                }
+            }
+        },
+        "slcansvc.ErrorEvent": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "description": "Error classes reported by an error frame",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "ack",
+                        "crc"
+                    ]
+                },
+                "received_at": {
+                    "description": "Host reception time",
+                    "type": "string"
+                },
+                "rx_errors": {
+                    "description": "RX error counter reported by a state frame",
+                    "type": "integer",
+                    "example": 0
+                },
+                "state": {
+                    "description": "Controller state reported by a state frame",
+                    "type": "string",
+                    "example": "passive"
+                },
+                "tx_errors": {
+                    "description": "TX error counter reported by a state frame",
+                    "type": "integer",
+                    "example": 128
+                }
+            }
+        },
+        "slcansvc.ErrorReport": {
+            "type": "object",
+            "properties": {
+                "counters": {
+                    "description": "Number of errors reported per error class and controller state",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "dropped": {
+                    "description": "Number of error events evicted from the bounded log",
+                    "type": "integer"
+                },
+                "events": {
+                    "description": "Most recent error events, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slcansvc.ErrorEvent"
+                    }
+                }
+            }
+        },
+        "slcansvc.Filter": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "integer",
+                    "example": 256
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "mask": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "to": {
+                    "type": "integer",
+                    "example": 511
+                },
+                "type": {
+                    "type": "string",
+                    "example": "std"
+                }
+            }
+        },
+        "slcansvc.HistoryStats": {
+            "type": "object",
+            "properties": {
+                "bytes": {
+                    "description": "Estimated memory used by the frames kept, and its limit",
+                    "type": "integer",
+                    "example": 201600
+                },
+                "entries": {
+                    "type": "integer",
+                    "example": 1200
+                },
+                "evicted_age": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "evicted_memory": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "evicted_size": {
+                    "description": "Frames evicted as the ring of their CAN ID was full, as they grew\nolder than the age limit, or to stay within the memory limit",
+                    "type": "integer",
+                    "example": 3400
+                },
+                "ids": {
+                    "type": "integer",
+                    "example": 12
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 67108864
+                }
+            }
+        },
+        "slcansvc.Job": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "failed": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "message": {
+                    "$ref": "#/definitions/slcansvc.Message"
+                },
+                "period": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "sent": {
+                    "description": "Frames transmitted and failed since the job was last started",
+                    "type": "integer",
+                    "example": 42
+                },
+                "state": {
+                    "description": "Running jobs are paused while the backend is on hold",
+                    "type": "string",
+                    "enum": [
+                        "stopped",
+                        "running",
+                        "paused"
+                    ],
+                    "example": "running"
+                }
+            }
+        },
+        "slcansvc.Message": {
+            "type": "object",
+            "properties": {
+                "brs": {
+                    "description": "BRS switches a CAN FD frame to the data phase bitrate",
+                    "type": "boolean",
+                    "example": false
+                },
+                "data": {
+                    "description": "Data is the frame payload, encoded in JSON as a hex string by\ndefault, a base64 string or an array of bytes as negotiated",
+                    "type": "string",
+                    "format": "hex",
+                    "example": "32303072706d"
+                },
+                "direction": {
+                    "description": "Direction tells messages configured for transmission from frames\nreceived, set by the database",
+                    "type": "string",
+                    "enum": [
+                        "tx",
+                        "rx"
+                    ],
+                    "readOnly": true,
+                    "example": "rx"
+                },
+                "dlc": {
+                    "description": "DLC is the data length requested by a remote transmission request",
+                    "type": "integer",
+                    "example": 0
+                },
+                "extended": {
+                    "description": "Extended marks a frame with a 29 bit ID, implied by IDs above 0x7ff",
+                    "type": "boolean",
+                    "example": false
+                },
+                "fd": {
+                    "description": "FD marks a CAN FD frame carrying up to 64 bytes of data",
+                    "type": "boolean",
+                    "example": false
+                },
+                "id": {
+                    "type": "integer",
+                    "example": 123
+                },
+                "received_at": {
+                    "description": "ReceivedAt is the host reception time",
+                    "type": "string",
+                    "example": "2023-06-01T12:00:00Z"
+                },
+                "rtr": {
+                    "description": "RTR marks a remote transmission request",
+                    "type": "boolean",
+                    "example": false
+                },
+                "timestamp": {
+                    "description": "Timestamp is the SLCAN device reception time in milliseconds",
+                    "type": "integer",
+                    "example": 1234
+                }
+            }
+        },
+        "slcansvc.MessageList": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer",
+                    "example": 100
+                },
+                "messages": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/slcansvc.Message"
+                    }
+                },
+                "offset": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "total": {
+                    "type": "integer",
+                    "example": 250
+                }
+            }
+        },
+        "slcansvc.RemoteRequest": {
+            "type": "object",
+            "properties": {
+                "dlc": {
+                    "type": "integer",
+                    "example": 8
+                },
+                "timeout": {
+                    "type": "integer",
+                    "example": 500
+                }
+            }
+        },
+        "slcansvc.Status": {
+            "type": "object",
+            "properties": {
+                "flags": {
+                    "description": "Status flags last read from the SLCAN device",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/slcansvc.StatusFlags"
+                        }
+                    ]
+                },
+                "link": {
+                    "description": "State of the link to the SLCAN device",
+                    "type": "string",
+                    "enum": [
+                        "connecting",
+                        "connected",
+                        "reconnecting",
+                        "rebooting",
+                        "closed"
+                    ],
+                    "example": "connected"
+                },
+                "mode": {
+                    "type": "string",
+                    "example": "normal"
+                },
+                "reconnects": {
+                    "description": "Number of times the link was reconnected after being lost",
+                    "type": "integer",
+                    "example": 0
+                },
+                "recoveries": {
+                    "description": "Number of times the channel was reopened to recover from bus-off",
+                    "type": "integer",
+                    "example": 0
+                },
+                "state": {
+                    "description": "Controller state last reported by a state frame",
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "warning",
+                        "passive",
+                        "bus-off"
+                    ],
+                    "example": "active"
+                },
+                "updated_at": {
+                    "description": "Time the status flags were last read",
+                    "type": "string",
+                    "example": "2023-06-01T12:00:00Z"
+                }
+            }
+        },
+        "slcansvc.StatusFlags": {
+            "type": "object",
+            "properties": {
+                "arbitration_lost": {
+                    "type": "boolean"
+                },
+                "bus_error": {
+                    "description": "Bus error interrupt, raised on any bus error rather than on bus-off",
+                    "type": "boolean"
+                },
+                "data_overrun": {
+                    "type": "boolean"
+                },
+                "error_passive": {
+                    "type": "boolean"
+                },
+                "error_warning": {
+                    "type": "boolean"
+                },
+                "rx_fifo_full": {
+                    "type": "boolean"
+                },
+                "tx_fifo_full": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "slcansvc.setModeRequest": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "listen-only"
+                    ],
+                    "example": "listen-only"
                 }
             }
         }
@@ -234,7 +2162,7 @@ var SwaggerInfo = &swag.Spec{
 	BasePath:         "",
 	Schemes:          []string{},
 	Title:            "Serial-Line CAN Service API",
-	Description:      "",
+	Description:      "Every channel is served under /slcan/{channel}, and the default channel, the first one given, under /slcan as well.",
 	InfoInstanceName: "swagger",
 	SwaggerTemplate:  docTemplate,
 	LeftDelim:        "{{",
@@ -4,28 +4,79 @@ import (
 	"context"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	httptransport "github.com/go-kit/kit/transport/http"
 )
 
 type Endpoints struct {
-	GetMessageEndpoint    endpoint.Endpoint
-	PostMessageEndpoint   endpoint.Endpoint
-	PutMessageEndpoint    endpoint.Endpoint
-	DeleteMessageEndpoint endpoint.Endpoint
-	RebootEndpoint        endpoint.Endpoint
-	UnlockEndpoint        endpoint.Endpoint
+	GetTxMessageEndpoint    endpoint.Endpoint
+	PostTxMessageEndpoint   endpoint.Endpoint
+	PutTxMessageEndpoint    endpoint.Endpoint
+	DeleteTxMessageEndpoint endpoint.Endpoint
+	RequestMessageEndpoint  endpoint.Endpoint
+	RebootEndpoint          endpoint.Endpoint
+	UnlockEndpoint          endpoint.Endpoint
+	GetBitrateEndpoint      endpoint.Endpoint
+	SetBitrateEndpoint      endpoint.Endpoint
+	GetAcceptanceEndpoint   endpoint.Endpoint
+	SetAcceptanceEndpoint   endpoint.Endpoint
+	GetFiltersEndpoint      endpoint.Endpoint
+	SetFiltersEndpoint      endpoint.Endpoint
+	SetModeEndpoint         endpoint.Endpoint
+	GetStatusEndpoint       endpoint.Endpoint
+	GetDeviceEndpoint       endpoint.Endpoint
+	GetErrorsEndpoint       endpoint.Endpoint
+	ClearErrorsEndpoint     endpoint.Endpoint
+	GetJobsEndpoint         endpoint.Endpoint
+	PostJobEndpoint         endpoint.Endpoint
+	GetJobEndpoint          endpoint.Endpoint
+	PutJobEndpoint          endpoint.Endpoint
+	DeleteJobEndpoint       endpoint.Endpoint
+	StartJobEndpoint        endpoint.Endpoint
+	StopJobEndpoint         endpoint.Endpoint
+	GetHistoryEndpoint      endpoint.Endpoint
+	GetHistoryStatsEndpoint endpoint.Endpoint
+	ListTxMessagesEndpoint  endpoint.Endpoint
+	ListRxMessagesEndpoint  endpoint.Endpoint
+	GetRxMessageEndpoint    endpoint.Endpoint
+	DeleteRxMessageEndpoint endpoint.Endpoint
 }
 
 func MakeServerEndpoints(s IService) Endpoints {
 	return Endpoints{
-		GetMessageEndpoint:    MakeGetMessageEndpoint(s),
-		PostMessageEndpoint:   MakePostMessageEndpoint(s),
-		PutMessageEndpoint:    MakePutMessageEndpoint(s),
-		DeleteMessageEndpoint: MakeDeleteMessageEndpoint(s),
-		RebootEndpoint:        MakeRebootEndpoint(s),
-		UnlockEndpoint:        MakeUnlockEndpoint(s),
+		GetTxMessageEndpoint:    MakeGetTxMessageEndpoint(s),
+		PostTxMessageEndpoint:   MakePostTxMessageEndpoint(s),
+		PutTxMessageEndpoint:    MakePutTxMessageEndpoint(s),
+		DeleteTxMessageEndpoint: MakeDeleteTxMessageEndpoint(s),
+		RequestMessageEndpoint:  MakeRequestMessageEndpoint(s),
+		RebootEndpoint:          MakeRebootEndpoint(s),
+		UnlockEndpoint:          MakeUnlockEndpoint(s),
+		GetBitrateEndpoint:      MakeGetBitrateEndpoint(s),
+		SetBitrateEndpoint:      MakeSetBitrateEndpoint(s),
+		GetAcceptanceEndpoint:   MakeGetAcceptanceEndpoint(s),
+		SetAcceptanceEndpoint:   MakeSetAcceptanceEndpoint(s),
+		GetFiltersEndpoint:      MakeGetFiltersEndpoint(s),
+		SetFiltersEndpoint:      MakeSetFiltersEndpoint(s),
+		SetModeEndpoint:         MakeSetModeEndpoint(s),
+		GetStatusEndpoint:       MakeGetStatusEndpoint(s),
+		GetDeviceEndpoint:       MakeGetDeviceEndpoint(s),
+		GetErrorsEndpoint:       MakeGetErrorsEndpoint(s),
+		ClearErrorsEndpoint:     MakeClearErrorsEndpoint(s),
+		GetJobsEndpoint:         MakeGetJobsEndpoint(s),
+		PostJobEndpoint:         MakePostJobEndpoint(s),
+		GetJobEndpoint:          MakeGetJobEndpoint(s),
+		PutJobEndpoint:          MakePutJobEndpoint(s),
+		DeleteJobEndpoint:       MakeDeleteJobEndpoint(s),
+		StartJobEndpoint:        MakeStartJobEndpoint(s),
+		StopJobEndpoint:         MakeStopJobEndpoint(s),
+		GetHistoryEndpoint:      MakeGetHistoryEndpoint(s),
+		GetHistoryStatsEndpoint: MakeGetHistoryStatsEndpoint(s),
+		ListTxMessagesEndpoint:  MakeListTxMessagesEndpoint(s),
+		ListRxMessagesEndpoint:  MakeListRxMessagesEndpoint(s),
+		GetRxMessageEndpoint:    MakeGetRxMessageEndpoint(s),
+		DeleteRxMessageEndpoint: MakeDeleteRxMessageEndpoint(s),
 	}
 }
 
@@ -48,64 +99,144 @@ func MakeClientEndpoints(instance string) (Endpoints, error) {
 	// each endpoint.
 
 	return Endpoints{
-		GetMessageEndpoint: httptransport.NewClient("GET", tgt,
-			EncodeGetMessageRequest, DecodeGetMessageResponse, options...).Endpoint(),
-		PostMessageEndpoint: httptransport.NewClient("POST", tgt,
-			EncodePostMessageRequest, DecodePostMessageResponse, options...).Endpoint(),
-		PutMessageEndpoint: httptransport.NewClient("PUT", tgt,
-			EncodePutMessageRequest, DecodePutMessageResponse, options...).Endpoint(),
-		DeleteMessageEndpoint: httptransport.NewClient("DELETE", tgt,
-			EncodeDeleteMessageRequest, DecodeDeleteMessageResponse, options...).Endpoint(),
+		GetTxMessageEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetTxMessageRequest, DecodeGetTxMessageResponse, options...).Endpoint(),
+		PostTxMessageEndpoint: httptransport.NewClient("POST", tgt,
+			EncodePostTxMessageRequest, DecodePostTxMessageResponse, options...).Endpoint(),
+		PutTxMessageEndpoint: httptransport.NewClient("PUT", tgt,
+			EncodePutTxMessageRequest, DecodePutTxMessageResponse, options...).Endpoint(),
+		DeleteTxMessageEndpoint: httptransport.NewClient("DELETE", tgt,
+			EncodeDeleteTxMessageRequest, DecodeDeleteTxMessageResponse, options...).Endpoint(),
+		RequestMessageEndpoint: httptransport.NewClient("POST", tgt,
+			EncodeRequestMessageRequest, DecodeRequestMessageResponse, options...).Endpoint(),
 		RebootEndpoint: httptransport.NewClient("POST", tgt,
 			EncodeRebootRequest, DecodeRebootResponse, options...).Endpoint(),
 		UnlockEndpoint: httptransport.NewClient("POST", tgt,
 			EncodeUnlockRequest, DecodeUnlockResponse, options...).Endpoint(),
+		GetBitrateEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetBitrateRequest, DecodeGetBitrateResponse, options...).Endpoint(),
+		SetBitrateEndpoint: httptransport.NewClient("POST", tgt,
+			EncodeSetBitrateRequest, DecodeSetBitrateResponse, options...).Endpoint(),
+		GetAcceptanceEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetAcceptanceRequest, DecodeGetAcceptanceResponse, options...).Endpoint(),
+		SetAcceptanceEndpoint: httptransport.NewClient("POST", tgt,
+			EncodeSetAcceptanceRequest, DecodeSetAcceptanceResponse, options...).Endpoint(),
+		GetFiltersEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetFiltersRequest, DecodeGetFiltersResponse, options...).Endpoint(),
+		SetFiltersEndpoint: httptransport.NewClient("POST", tgt,
+			EncodeSetFiltersRequest, DecodeSetFiltersResponse, options...).Endpoint(),
+		SetModeEndpoint: httptransport.NewClient("POST", tgt,
+			EncodeSetModeRequest, DecodeSetModeResponse, options...).Endpoint(),
+		GetStatusEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetStatusRequest, DecodeGetStatusResponse, options...).Endpoint(),
+		GetDeviceEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetDeviceRequest, DecodeGetDeviceResponse, options...).Endpoint(),
+		GetErrorsEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetErrorsRequest, DecodeGetErrorsResponse, options...).Endpoint(),
+		ClearErrorsEndpoint: httptransport.NewClient("DELETE", tgt,
+			EncodeClearErrorsRequest, DecodeClearErrorsResponse, options...).Endpoint(),
+		GetJobsEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetJobsRequest, DecodeGetJobsResponse, options...).Endpoint(),
+		PostJobEndpoint: httptransport.NewClient("POST", tgt,
+			EncodePostJobRequest, DecodePostJobResponse, options...).Endpoint(),
+		GetJobEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetJobRequest, DecodeGetJobResponse, options...).Endpoint(),
+		PutJobEndpoint: httptransport.NewClient("PUT", tgt,
+			EncodePutJobRequest, DecodePutJobResponse, options...).Endpoint(),
+		DeleteJobEndpoint: httptransport.NewClient("DELETE", tgt,
+			EncodeDeleteJobRequest, DecodeDeleteJobResponse, options...).Endpoint(),
+		StartJobEndpoint: httptransport.NewClient("POST", tgt,
+			EncodeStartJobRequest, DecodeStartJobResponse, options...).Endpoint(),
+		StopJobEndpoint: httptransport.NewClient("POST", tgt,
+			EncodeStopJobRequest, DecodeStopJobResponse, options...).Endpoint(),
+		GetHistoryEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetHistoryRequest, DecodeGetHistoryResponse, options...).Endpoint(),
+		GetHistoryStatsEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetHistoryStatsRequest, DecodeGetHistoryStatsResponse, options...).Endpoint(),
+		ListTxMessagesEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeListTxMessagesRequest, DecodeListTxMessagesResponse, options...).Endpoint(),
+		ListRxMessagesEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeListRxMessagesRequest, DecodeListRxMessagesResponse, options...).Endpoint(),
+		GetRxMessageEndpoint: httptransport.NewClient("GET", tgt,
+			EncodeGetRxMessageRequest, DecodeGetRxMessageResponse, options...).Endpoint(),
+		DeleteRxMessageEndpoint: httptransport.NewClient("DELETE", tgt,
+			EncodeDeleteRxMessageRequest, DecodeDeleteRxMessageResponse, options...).Endpoint(),
 	}, nil
 }
 
 // struct Endpoints implements interface IService. Primarily useful in a client.
-func (e Endpoints) GetMessage(ctx context.Context, id int) (Message, error) {
-	request := getMessageRequest{ID: id}
-	response, err := e.GetMessageEndpoint(ctx, request)
+func (e Endpoints) GetTxMessage(ctx context.Context, id int) (Message, error) {
+	request := getTxMessageRequest{ID: id}
+	response, err := e.GetTxMessageEndpoint(ctx, request)
 	if err != nil {
 		return Message{}, err
 	}
-	resp := response.(getMessageResponse)
+	resp := response.(getTxMessageResponse)
 	return resp.Msg, resp.Err
 }
 
-func (e Endpoints) PostMessage(ctx context.Context, m Message) error {
-	request := postMessageRequest{Msg: m}
-	response, err := e.PostMessageEndpoint(ctx, request)
+func (e Endpoints) GetRxMessage(ctx context.Context, id int) (Message, error) {
+	request := getRxMessageRequest{ID: id}
+	response, err := e.GetRxMessageEndpoint(ctx, request)
+	if err != nil {
+		return Message{}, err
+	}
+	resp := response.(getRxMessageResponse)
+	return resp.Msg, resp.Err
+}
+
+func (e Endpoints) PostTxMessage(ctx context.Context, m Message) error {
+	request := postTxMessageRequest{Msg: m}
+	response, err := e.PostTxMessageEndpoint(ctx, request)
+	if err != nil {
+		return err
+	}
+	resp := response.(postTxMessageResponse)
+	return resp.Err
+}
+
+func (e Endpoints) PutTxMessage(ctx context.Context, id int, m Message) error {
+	request := putTxMessageRequest{ID: id, Msg: m}
+	response, err := e.PutTxMessageEndpoint(ctx, request)
 	if err != nil {
 		return err
 	}
-	resp := response.(postMessageResponse)
+	resp := response.(putTxMessageResponse)
 	return resp.Err
 }
 
-func (e Endpoints) PutMessage(ctx context.Context, id int, m Message) error {
-	request := putMessageRequest{ID: id, Msg: m}
-	response, err := e.PutMessageEndpoint(ctx, request)
+func (e Endpoints) DeleteTxMessage(ctx context.Context, id int) error {
+	request := deleteTxMessageRequest{ID: id}
+	response, err := e.DeleteTxMessageEndpoint(ctx, request)
 	if err != nil {
 		return err
 	}
-	resp := response.(putMessageResponse)
+	resp := response.(deleteTxMessageResponse)
 	return resp.Err
 }
 
-func (e Endpoints) DeleteMessage(ctx context.Context, id int) error {
-	request := deleteMessageRequest{ID: id}
-	response, err := e.DeleteMessageEndpoint(ctx, request)
+func (e Endpoints) DeleteRxMessage(ctx context.Context, id int) error {
+	request := deleteRxMessageRequest{ID: id}
+	response, err := e.DeleteRxMessageEndpoint(ctx, request)
 	if err != nil {
 		return err
 	}
-	resp := response.(deleteMessageResponse)
+	resp := response.(deleteRxMessageResponse)
 	return resp.Err
 }
 
+func (e Endpoints) RequestMessage(ctx context.Context, id int, r RemoteRequest) (Message, error) {
+	request := requestMessageRequest{ID: id, Req: r}
+	response, err := e.RequestMessageEndpoint(ctx, request)
+	if err != nil {
+		return Message{}, err
+	}
+	resp := response.(requestMessageResponse)
+	return resp.Msg, resp.Err
+}
+
 func (e Endpoints) Reboot(ctx context.Context) error {
-	response, err := e.DeleteMessageEndpoint(ctx, rebootRequest{})
+	response, err := e.DeleteTxMessageEndpoint(ctx, rebootRequest{})
 	if err != nil {
 		return err
 	}
@@ -114,7 +245,7 @@ func (e Endpoints) Reboot(ctx context.Context) error {
 }
 
 func (e Endpoints) Unlock(ctx context.Context) error {
-	response, err := e.DeleteMessageEndpoint(ctx, unlockRequest{})
+	response, err := e.DeleteTxMessageEndpoint(ctx, unlockRequest{})
 	if err != nil {
 		return err
 	}
@@ -122,35 +253,257 @@ func (e Endpoints) Unlock(ctx context.Context) error {
 	return resp.Err
 }
 
-func MakeGetMessageEndpoint(s IService) endpoint.Endpoint {
+func (e Endpoints) GetBitrate(ctx context.Context) (Bitrate, error) {
+	response, err := e.GetBitrateEndpoint(ctx, getBitrateRequest{})
+	if err != nil {
+		return Bitrate{}, err
+	}
+	resp := response.(getBitrateResponse)
+	return resp.Bitrate, resp.Err
+}
+
+func (e Endpoints) SetBitrate(ctx context.Context, br Bitrate) error {
+	response, err := e.SetBitrateEndpoint(ctx, setBitrateRequest{Bitrate: br})
+	if err != nil {
+		return err
+	}
+	resp := response.(setBitrateResponse)
+	return resp.Err
+}
+
+func (e Endpoints) GetAcceptance(ctx context.Context) (Acceptance, error) {
+	response, err := e.GetAcceptanceEndpoint(ctx, getAcceptanceRequest{})
+	if err != nil {
+		return Acceptance{}, err
+	}
+	resp := response.(getAcceptanceResponse)
+	return resp.Acceptance, resp.Err
+}
+
+func (e Endpoints) SetAcceptance(ctx context.Context, a Acceptance) error {
+	response, err := e.SetAcceptanceEndpoint(ctx, setAcceptanceRequest{Acceptance: a})
+	if err != nil {
+		return err
+	}
+	resp := response.(setAcceptanceResponse)
+	return resp.Err
+}
+
+func (e Endpoints) GetFilters(ctx context.Context) ([]Filter, error) {
+	response, err := e.GetFiltersEndpoint(ctx, getFiltersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(getFiltersResponse)
+	return resp.Filters, resp.Err
+}
+
+func (e Endpoints) SetFilters(ctx context.Context, f []Filter) error {
+	response, err := e.SetFiltersEndpoint(ctx, setFiltersRequest{Filters: f})
+	if err != nil {
+		return err
+	}
+	resp := response.(setFiltersResponse)
+	return resp.Err
+}
+
+func (e Endpoints) SetMode(ctx context.Context, mode string) error {
+	response, err := e.SetModeEndpoint(ctx, setModeRequest{Mode: mode})
+	if err != nil {
+		return err
+	}
+	resp := response.(setModeResponse)
+	return resp.Err
+}
+
+func (e Endpoints) GetStatus(ctx context.Context) (Status, error) {
+	response, err := e.GetStatusEndpoint(ctx, getStatusRequest{})
+	if err != nil {
+		return Status{}, err
+	}
+	resp := response.(getStatusResponse)
+	return resp.Status, resp.Err
+}
+
+func (e Endpoints) GetDevice(ctx context.Context, refresh bool) (DeviceInfo, error) {
+	response, err := e.GetDeviceEndpoint(ctx, getDeviceRequest{Refresh: refresh})
+	if err != nil {
+		return DeviceInfo{}, err
+	}
+	resp := response.(getDeviceResponse)
+	return resp.Device, resp.Err
+}
+
+func (e Endpoints) GetErrors(ctx context.Context) (ErrorReport, error) {
+	response, err := e.GetErrorsEndpoint(ctx, getErrorsRequest{})
+	if err != nil {
+		return ErrorReport{}, err
+	}
+	resp := response.(getErrorsResponse)
+	return resp.Errors, resp.Err
+}
+
+func (e Endpoints) ClearErrors(ctx context.Context) error {
+	response, err := e.ClearErrorsEndpoint(ctx, clearErrorsRequest{})
+	if err != nil {
+		return err
+	}
+	resp := response.(clearErrorsResponse)
+	return resp.Err
+}
+
+func (e Endpoints) GetJobs(ctx context.Context) ([]Job, error) {
+	response, err := e.GetJobsEndpoint(ctx, getJobsRequest{})
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(getJobsResponse)
+	return resp.Jobs, resp.Err
+}
+
+func (e Endpoints) PostJob(ctx context.Context, j Job) (Job, error) {
+	response, err := e.PostJobEndpoint(ctx, postJobRequest{Job: j})
+	if err != nil {
+		return Job{}, err
+	}
+	resp := response.(postJobResponse)
+	return resp.Job, resp.Err
+}
+
+func (e Endpoints) GetJob(ctx context.Context, id int) (Job, error) {
+	response, err := e.GetJobEndpoint(ctx, getJobRequest{ID: id})
+	if err != nil {
+		return Job{}, err
+	}
+	resp := response.(getJobResponse)
+	return resp.Job, resp.Err
+}
+
+func (e Endpoints) PutJob(ctx context.Context, id int, j Job) (Job, error) {
+	response, err := e.PutJobEndpoint(ctx, putJobRequest{ID: id, Job: j})
+	if err != nil {
+		return Job{}, err
+	}
+	resp := response.(putJobResponse)
+	return resp.Job, resp.Err
+}
+
+func (e Endpoints) DeleteJob(ctx context.Context, id int) error {
+	response, err := e.DeleteJobEndpoint(ctx, deleteJobRequest{ID: id})
+	if err != nil {
+		return err
+	}
+	resp := response.(deleteJobResponse)
+	return resp.Err
+}
+
+func (e Endpoints) StartJob(ctx context.Context, id int) (Job, error) {
+	response, err := e.StartJobEndpoint(ctx, startJobRequest{ID: id})
+	if err != nil {
+		return Job{}, err
+	}
+	resp := response.(startJobResponse)
+	return resp.Job, resp.Err
+}
+
+func (e Endpoints) StopJob(ctx context.Context, id int) (Job, error) {
+	response, err := e.StopJobEndpoint(ctx, stopJobRequest{ID: id})
+	if err != nil {
+		return Job{}, err
+	}
+	resp := response.(stopJobResponse)
+	return resp.Job, resp.Err
+}
+
+func (e Endpoints) GetHistory(ctx context.Context, id int, since time.Time, limit int) ([]Message, error) {
+	response, err := e.GetHistoryEndpoint(ctx, getHistoryRequest{ID: id, Since: since, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	resp := response.(getHistoryResponse)
+	return resp.History, resp.Err
+}
+
+func (e Endpoints) GetHistoryStats(ctx context.Context) (HistoryStats, error) {
+	response, err := e.GetHistoryStatsEndpoint(ctx, getHistoryStatsRequest{})
+	if err != nil {
+		return HistoryStats{}, err
+	}
+	resp := response.(getHistoryStatsResponse)
+	return resp.Stats, resp.Err
+}
+
+func (e Endpoints) ListTxMessages(ctx context.Context, q MessageQuery) (MessageList, error) {
+	response, err := e.ListTxMessagesEndpoint(ctx, listTxMessagesRequest{Query: q})
+	if err != nil {
+		return MessageList{}, err
+	}
+	resp := response.(listTxMessagesResponse)
+	return resp.MessageList, resp.Err
+}
+
+func (e Endpoints) ListRxMessages(ctx context.Context, q MessageQuery) (MessageList, error) {
+	response, err := e.ListRxMessagesEndpoint(ctx, listRxMessagesRequest{Query: q})
+	if err != nil {
+		return MessageList{}, err
+	}
+	resp := response.(listRxMessagesResponse)
+	return resp.MessageList, resp.Err
+}
+
+func MakeGetTxMessageEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getTxMessageRequest)
+		m, e := s.GetTxMessage(ctx, req.ID)
+		return getTxMessageResponse{Msg: m, Err: e}, nil
+	}
+}
+
+func MakeGetRxMessageEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getRxMessageRequest)
+		m, e := s.GetRxMessage(ctx, req.ID)
+		return getRxMessageResponse{Msg: m, Err: e}, nil
+	}
+}
+
+func MakePostTxMessageEndpoint(s IService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		req := request.(getMessageRequest)
-		m, e := s.GetMessage(ctx, req.ID)
-		return getMessageResponse{Msg: m, Err: e}, nil
+		req := request.(postTxMessageRequest)
+		e := s.PostTxMessage(ctx, req.Msg)
+		return postTxMessageResponse{Err: e}, nil
 	}
 }
 
-func MakePostMessageEndpoint(s IService) endpoint.Endpoint {
+func MakePutTxMessageEndpoint(s IService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		req := request.(postMessageRequest)
-		e := s.PostMessage(ctx, req.Msg)
-		return postMessageResponse{Err: e}, nil
+		req := request.(putTxMessageRequest)
+		e := s.PutTxMessage(ctx, req.ID, req.Msg)
+		return putTxMessageResponse{Err: e}, nil
 	}
 }
 
-func MakePutMessageEndpoint(s IService) endpoint.Endpoint {
+func MakeDeleteTxMessageEndpoint(s IService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		req := request.(putMessageRequest)
-		e := s.PutMessage(ctx, req.ID, req.Msg)
-		return putMessageResponse{Err: e}, nil
+		req := request.(deleteTxMessageRequest)
+		e := s.DeleteTxMessage(ctx, req.ID)
+		return deleteTxMessageResponse{Err: e}, nil
 	}
 }
 
-func MakeDeleteMessageEndpoint(s IService) endpoint.Endpoint {
+func MakeDeleteRxMessageEndpoint(s IService) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
-		req := request.(deleteMessageRequest)
-		e := s.DeleteMessage(ctx, req.ID)
-		return deleteMessageResponse{Err: e}, nil
+		req := request.(deleteRxMessageRequest)
+		e := s.DeleteRxMessage(ctx, req.ID)
+		return deleteRxMessageResponse{Err: e}, nil
+	}
+}
+
+func MakeRequestMessageEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(requestMessageRequest)
+		m, e := s.RequestMessage(ctx, req.ID, req.Req)
+		return requestMessageResponse{Msg: m, Err: e}, nil
 	}
 }
 
@@ -170,48 +523,492 @@ func MakeUnlockEndpoint(s IService) endpoint.Endpoint {
 	}
 }
 
-type getMessageRequest struct {
+func MakeGetBitrateEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_ = request.(getBitrateRequest)
+		br, e := s.GetBitrate(ctx)
+		return getBitrateResponse{Bitrate: br, Err: e}, nil
+	}
+}
+
+func MakeSetBitrateEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(setBitrateRequest)
+		e := s.SetBitrate(ctx, req.Bitrate)
+		return setBitrateResponse{Err: e}, nil
+	}
+}
+
+func MakeGetAcceptanceEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_ = request.(getAcceptanceRequest)
+		d, e := s.GetAcceptance(ctx)
+		return getAcceptanceResponse{Acceptance: d, Err: e}, nil
+	}
+}
+
+func MakeSetAcceptanceEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(setAcceptanceRequest)
+		e := s.SetAcceptance(ctx, req.Acceptance)
+		return setAcceptanceResponse{Err: e}, nil
+	}
+}
+
+func MakeGetFiltersEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_ = request.(getFiltersRequest)
+		d, e := s.GetFilters(ctx)
+		return getFiltersResponse{Filters: d, Err: e}, nil
+	}
+}
+
+func MakeSetFiltersEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(setFiltersRequest)
+		e := s.SetFilters(ctx, req.Filters)
+		return setFiltersResponse{Err: e}, nil
+	}
+}
+
+func MakeSetModeEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(setModeRequest)
+		e := s.SetMode(ctx, req.Mode)
+		return setModeResponse{Err: e}, nil
+	}
+}
+
+func MakeGetStatusEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_ = request.(getStatusRequest)
+		d, e := s.GetStatus(ctx)
+		return getStatusResponse{Status: d, Err: e}, nil
+	}
+}
+
+func MakeGetDeviceEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getDeviceRequest)
+		d, e := s.GetDevice(ctx, req.Refresh)
+		return getDeviceResponse{Device: d, Err: e}, nil
+	}
+}
+
+func MakeGetErrorsEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_ = request.(getErrorsRequest)
+		r, e := s.GetErrors(ctx)
+		return getErrorsResponse{Errors: r, Err: e}, nil
+	}
+}
+
+func MakeClearErrorsEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_ = request.(clearErrorsRequest)
+		e := s.ClearErrors(ctx)
+		return clearErrorsResponse{Err: e}, nil
+	}
+}
+
+func MakeGetJobsEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_ = request.(getJobsRequest)
+		j, e := s.GetJobs(ctx)
+		return getJobsResponse{Jobs: j, Err: e}, nil
+	}
+}
+
+func MakePostJobEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(postJobRequest)
+		j, e := s.PostJob(ctx, req.Job)
+		return postJobResponse{Job: j, Err: e}, nil
+	}
+}
+
+func MakeGetJobEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getJobRequest)
+		j, e := s.GetJob(ctx, req.ID)
+		return getJobResponse{Job: j, Err: e}, nil
+	}
+}
+
+func MakePutJobEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(putJobRequest)
+		j, e := s.PutJob(ctx, req.ID, req.Job)
+		return putJobResponse{Job: j, Err: e}, nil
+	}
+}
+
+func MakeDeleteJobEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(deleteJobRequest)
+		e := s.DeleteJob(ctx, req.ID)
+		return deleteJobResponse{Err: e}, nil
+	}
+}
+
+func MakeStartJobEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(startJobRequest)
+		j, e := s.StartJob(ctx, req.ID)
+		return startJobResponse{Job: j, Err: e}, nil
+	}
+}
+
+func MakeStopJobEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(stopJobRequest)
+		j, e := s.StopJob(ctx, req.ID)
+		return stopJobResponse{Job: j, Err: e}, nil
+	}
+}
+
+func MakeGetHistoryEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(getHistoryRequest)
+		h, e := s.GetHistory(ctx, req.ID, req.Since, req.Limit)
+		return getHistoryResponse{History: h, Err: e}, nil
+	}
+}
+
+func MakeGetHistoryStatsEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		_ = request.(getHistoryStatsRequest)
+		st, e := s.GetHistoryStats(ctx)
+		return getHistoryStatsResponse{Stats: st, Err: e}, nil
+	}
+}
+
+func MakeListTxMessagesEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(listTxMessagesRequest)
+		l, e := s.ListTxMessages(ctx, req.Query)
+		return listTxMessagesResponse{MessageList: l, Err: e}, nil
+	}
+}
+
+func MakeListRxMessagesEndpoint(s IService) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (response interface{}, err error) {
+		req := request.(listRxMessagesRequest)
+		l, e := s.ListRxMessages(ctx, req.Query)
+		return listRxMessagesResponse{MessageList: l, Err: e}, nil
+	}
+}
+
+type getTxMessageRequest struct {
 	ID int
 }
 
-type getMessageResponse struct {
+type getTxMessageResponse struct {
 	Msg Message `json:"message,omitempty"`
 	Err error   `json:"err,omitempty"`
 }
 
-type postMessageRequest struct {
+func (r getTxMessageResponse) error() error { return r.Err }
+
+type getRxMessageRequest struct {
+	ID int
+}
+
+type getRxMessageResponse struct {
 	Msg Message `json:"message,omitempty"`
+	Err error   `json:"err,omitempty"`
 }
 
-type postMessageResponse struct {
+func (r getRxMessageResponse) error() error { return r.Err }
+
+type postTxMessageRequest struct {
+	Msg Message `json:"message,omitempty"`
+}
+
+type postTxMessageResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
-type putMessageRequest struct {
+func (r postTxMessageResponse) error() error { return r.Err }
+
+type putTxMessageRequest struct {
 	ID  int
 	Msg Message `json:"message,omitempty"`
 }
 
-type putMessageResponse struct {
+type putTxMessageResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r putTxMessageResponse) error() error { return r.Err }
+
+type deleteTxMessageRequest struct {
+	ID int
+}
+
+type deleteTxMessageResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
-type deleteMessageRequest struct {
+func (r deleteTxMessageResponse) error() error { return r.Err }
+
+type deleteRxMessageRequest struct {
 	ID int
 }
 
-type deleteMessageResponse struct {
+type deleteRxMessageResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
+func (r deleteRxMessageResponse) error() error { return r.Err }
+
+type requestMessageRequest struct {
+	ID  int
+	Req RemoteRequest `json:"request,omitempty"`
+}
+
+type requestMessageResponse struct {
+	Msg Message `json:"message,omitempty"`
+	Err error   `json:"err,omitempty"`
+}
+
+func (r requestMessageResponse) error() error { return r.Err }
+
 type rebootRequest struct{}
 
 type rebootResponse struct {
 	Err error `json:"err,omitempty"`
 }
 
+func (r rebootResponse) error() error { return r.Err }
+
 type unlockRequest struct{}
 
 type unlockResponse struct {
 	Err error `json:"err,omitempty"`
 }
+
+func (r unlockResponse) error() error { return r.Err }
+
+type getBitrateRequest struct{}
+
+type getBitrateResponse struct {
+	Bitrate Bitrate `json:"bitrate,omitempty"`
+	Err     error   `json:"err,omitempty"`
+}
+
+func (r getBitrateResponse) error() error { return r.Err }
+
+type setBitrateRequest struct {
+	Bitrate Bitrate `json:"bitrate,omitempty"`
+}
+
+type setBitrateResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r setBitrateResponse) error() error { return r.Err }
+
+type getAcceptanceRequest struct{}
+
+type getAcceptanceResponse struct {
+	Acceptance Acceptance `json:"acceptance,omitempty"`
+	Err        error      `json:"err,omitempty"`
+}
+
+func (r getAcceptanceResponse) error() error { return r.Err }
+
+type setAcceptanceRequest struct {
+	Acceptance Acceptance `json:"acceptance,omitempty"`
+}
+
+type setAcceptanceResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r setAcceptanceResponse) error() error { return r.Err }
+
+type getFiltersRequest struct{}
+
+type getFiltersResponse struct {
+	Filters []Filter `json:"filters"`
+	Err     error    `json:"err,omitempty"`
+}
+
+func (r getFiltersResponse) error() error { return r.Err }
+
+type setFiltersRequest struct {
+	Filters []Filter `json:"filters,omitempty"`
+}
+
+type setFiltersResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r setFiltersResponse) error() error { return r.Err }
+
+type setModeRequest struct {
+	Mode string `json:"mode" enums:"normal,listen-only" example:"listen-only"`
+}
+
+type setModeResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r setModeResponse) error() error { return r.Err }
+
+type getStatusRequest struct{}
+
+type getStatusResponse struct {
+	Status Status `json:"status,omitempty"`
+	Err    error  `json:"err,omitempty"`
+}
+
+func (r getStatusResponse) error() error { return r.Err }
+
+type getDeviceRequest struct {
+	Refresh bool
+}
+
+type getDeviceResponse struct {
+	Device DeviceInfo `json:"device,omitempty"`
+	Err    error      `json:"err,omitempty"`
+}
+
+func (r getDeviceResponse) error() error { return r.Err }
+
+type getErrorsRequest struct{}
+
+type getErrorsResponse struct {
+	Errors ErrorReport `json:"errors"`
+	Err    error       `json:"err,omitempty"`
+}
+
+func (r getErrorsResponse) error() error { return r.Err }
+
+type clearErrorsRequest struct{}
+
+type clearErrorsResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r clearErrorsResponse) error() error { return r.Err }
+
+type getJobsRequest struct{}
+
+type getJobsResponse struct {
+	Jobs []Job `json:"jobs,omitempty"`
+	Err  error `json:"err,omitempty"`
+}
+
+func (r getJobsResponse) error() error { return r.Err }
+
+type postJobRequest struct {
+	Job Job
+}
+
+type postJobResponse struct {
+	Job Job   `json:"job,omitempty"`
+	Err error `json:"err,omitempty"`
+}
+
+func (r postJobResponse) error() error { return r.Err }
+
+type getJobRequest struct {
+	ID int
+}
+
+type getJobResponse struct {
+	Job Job   `json:"job,omitempty"`
+	Err error `json:"err,omitempty"`
+}
+
+func (r getJobResponse) error() error { return r.Err }
+
+type putJobRequest struct {
+	ID  int
+	Job Job
+}
+
+type putJobResponse struct {
+	Job Job   `json:"job,omitempty"`
+	Err error `json:"err,omitempty"`
+}
+
+func (r putJobResponse) error() error { return r.Err }
+
+type deleteJobRequest struct {
+	ID int
+}
+
+type deleteJobResponse struct {
+	Err error `json:"err,omitempty"`
+}
+
+func (r deleteJobResponse) error() error { return r.Err }
+
+type startJobRequest struct {
+	ID int
+}
+
+type startJobResponse struct {
+	Job Job   `json:"job,omitempty"`
+	Err error `json:"err,omitempty"`
+}
+
+func (r startJobResponse) error() error { return r.Err }
+
+type stopJobRequest struct {
+	ID int
+}
+
+type stopJobResponse struct {
+	Job Job   `json:"job,omitempty"`
+	Err error `json:"err,omitempty"`
+}
+
+func (r stopJobResponse) error() error { return r.Err }
+
+type getHistoryRequest struct {
+	ID    int
+	Since time.Time
+	Limit int
+}
+
+type getHistoryResponse struct {
+	History []Message `json:"history,omitempty"`
+	Err     error     `json:"err,omitempty"`
+}
+
+func (r getHistoryResponse) error() error { return r.Err }
+
+type getHistoryStatsRequest struct{}
+
+type getHistoryStatsResponse struct {
+	Stats HistoryStats `json:"stats,omitempty"`
+	Err   error        `json:"err,omitempty"`
+}
+
+func (r getHistoryStatsResponse) error() error { return r.Err }
+
+type listTxMessagesRequest struct {
+	Query MessageQuery
+}
+
+type listTxMessagesResponse struct {
+	MessageList
+	Err error `json:"err,omitempty"`
+}
+
+func (r listTxMessagesResponse) error() error { return r.Err }
+
+type listRxMessagesRequest struct {
+	Query MessageQuery
+}
+
+type listRxMessagesResponse struct {
+	MessageList
+	Err error `json:"err,omitempty"`
+}
+
+func (r listRxMessagesResponse) error() error { return r.Err }
@@ -0,0 +1,176 @@
+package slcansvc
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrorEvent is a CAN bus error reported by the SLCAN device, either as an
+// error frame ("e") listing the error classes detected, or as a state frame
+// ("s") carrying the controller state and its TX/RX error counters.
+type ErrorEvent struct {
+	// Error classes reported by an error frame
+	Errors []string `json:"errors,omitempty" example:"ack,crc"`
+	// Controller state reported by a state frame
+	State string `json:"state,omitempty" example:"passive"`
+	// TX error counter reported by a state frame
+	TxErrors int `json:"tx_errors,omitempty" example:"128"`
+	// RX error counter reported by a state frame
+	RxErrors int `json:"rx_errors,omitempty" example:"0"`
+	// Host reception time
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// ErrorReport summarises the CAN bus errors reported by the SLCAN device
+// since the error log was last cleared.
+type ErrorReport struct {
+	// Most recent error events, oldest first
+	Events []ErrorEvent `json:"events"`
+	// Number of errors reported per error class and controller state
+	Counters map[string]int `json:"counters"`
+	// Number of error events evicted from the bounded log
+	Dropped int `json:"dropped"`
+}
+
+const (
+	ERROR_ACK        = "ack"
+	ERROR_BIT0       = "bit0"
+	ERROR_BIT1       = "bit1"
+	ERROR_CRC        = "crc"
+	ERROR_FORM       = "form"
+	ERROR_RX_OVERRUN = "rx-overrun"
+	ERROR_TX_OVERRUN = "tx-overrun"
+	ERROR_STUFF      = "stuff"
+)
+
+const (
+	STATE_ACTIVE  = "active"
+	STATE_WARNING = "warning"
+	STATE_PASSIVE = "passive"
+	STATE_BUS_OFF = "bus-off"
+)
+
+var slcanErrorClasses = map[byte]string{
+	'a': ERROR_ACK,
+	'b': ERROR_BIT0,
+	'B': ERROR_BIT1,
+	'c': ERROR_CRC,
+	'f': ERROR_FORM,
+	'o': ERROR_RX_OVERRUN,
+	'O': ERROR_TX_OVERRUN,
+	's': ERROR_STUFF,
+}
+
+var slcanStates = map[byte]string{
+	'a': STATE_ACTIVE,
+	'w': STATE_WARNING,
+	'p': STATE_PASSIVE,
+	'b': STATE_BUS_OFF,
+}
+
+// DefaultErrorLogSize is the default number of error events kept.
+const DefaultErrorLogSize = 100
+
+// errorLog keeps the most recent error events in a ring, along with
+// counters per error class which survive eviction
+type errorLog struct {
+	mtx      sync.Mutex
+	events   []ErrorEvent
+	next     int
+	size     int
+	counters map[string]int
+	dropped  int
+}
+
+func newErrorLog(size int) *errorLog {
+	return &errorLog{size: size, counters: make(map[string]int)}
+}
+
+func (l *errorLog) add(e ErrorEvent) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	for _, c := range e.Errors {
+		l.counters[c] += 1
+	}
+	if e.State != "" {
+		l.counters[e.State] += 1
+	}
+	if l.size <= 0 {
+		l.dropped += 1
+		return
+	}
+	if len(l.events) < l.size {
+		l.events = append(l.events, e)
+		return
+	}
+	l.events[l.next] = e
+	l.next = (l.next + 1) % l.size
+	l.dropped += 1
+}
+
+func (l *errorLog) report() ErrorReport {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	r := ErrorReport{
+		Events:   make([]ErrorEvent, 0, len(l.events)),
+		Counters: make(map[string]int, len(l.counters)),
+		Dropped:  l.dropped,
+	}
+	r.Events = append(r.Events, l.events[l.next:]...)
+	r.Events = append(r.Events, l.events[:l.next]...)
+	for c, n := range l.counters {
+		r.Counters[c] = n
+	}
+	return r
+}
+
+func (l *errorLog) clear() {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.events = nil
+	l.next = 0
+	l.counters = make(map[string]int)
+	l.dropped = 0
+}
+
+// decapsSlcanError decodes an error frame, e.g. "e2ac\r" for ACK and CRC
+// errors, or a state frame, e.g. "sp128000\r" for error-passive with TX and
+// RX error counters 128 and 0, as emitted by the Linux slcan driver
+func decapsSlcanError(f []byte) (ErrorEvent, error) {
+	var e ErrorEvent
+
+	switch {
+	case len(f) >= 3 && f[0] == 'e':
+		n, err := strconv.ParseUint(string(f[1:2]), 16, 8)
+		if err != nil || n == 0 || len(f) != int(n)+3 {
+			return ErrorEvent{}, ErrBackendInvalidFrame
+		}
+		for _, c := range f[2 : 2+n] {
+			class, ok := slcanErrorClasses[c]
+			if !ok {
+				return ErrorEvent{}, ErrBackendInvalidFrame
+			}
+			e.Errors = append(e.Errors, class)
+		}
+
+	case len(f) == 9 && f[0] == 's':
+		state, ok := slcanStates[f[1]]
+		if !ok {
+			return ErrorEvent{}, ErrBackendInvalidFrame
+		}
+		tx, err := strconv.ParseUint(string(f[2:5]), 10, 16)
+		if err != nil {
+			return ErrorEvent{}, ErrBackendInvalidFrame
+		}
+		rx, err := strconv.ParseUint(string(f[5:8]), 10, 16)
+		if err != nil {
+			return ErrorEvent{}, ErrBackendInvalidFrame
+		}
+		e.State, e.TxErrors, e.RxErrors = state, int(tx), int(rx)
+
+	default:
+		return ErrorEvent{}, ErrBackendInvalidFrame
+	}
+	return e, nil
+}
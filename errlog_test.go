@@ -0,0 +1,65 @@
+package slcansvc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecapsErrorFrame(t *testing.T) {
+	// error frames
+	e, err := decapsSlcanError([]byte("e1a\r"))
+	assert.Equal(t, ErrorEvent{Errors: []string{ERROR_ACK}}, e)
+	assert.Equal(t, nil, err)
+
+	e, err = decapsSlcanError([]byte("e3bcO\r"))
+	assert.Equal(t, ErrorEvent{Errors: []string{ERROR_BIT0, ERROR_CRC, ERROR_TX_OVERRUN}}, e)
+	assert.Equal(t, nil, err)
+
+	// state frames
+	e, err = decapsSlcanError([]byte("sp128000\r"))
+	assert.Equal(t, ErrorEvent{State: STATE_PASSIVE, TxErrors: 128}, e)
+	assert.Equal(t, nil, err)
+
+	e, err = decapsSlcanError([]byte("sb256256\r"))
+	assert.Equal(t, ErrorEvent{State: STATE_BUS_OFF, TxErrors: 256, RxErrors: 256}, e)
+	assert.Equal(t, nil, err)
+
+	// malformed frames
+	for _, f := range []string{"e0\r", "e2a\r", "e1x\r", "sx000000\r", "sa00000\r", "sa0000x0\r", "t1230\r"} {
+		_, err = decapsSlcanError([]byte(f))
+		assert.Equal(t, ErrBackendInvalidFrame, err, f)
+	}
+}
+
+func TestErrorLog(t *testing.T) {
+	l := newErrorLog(2)
+	l.add(ErrorEvent{Errors: []string{ERROR_ACK}})
+	l.add(ErrorEvent{Errors: []string{ERROR_ACK, ERROR_CRC}})
+	l.add(ErrorEvent{State: STATE_WARNING, TxErrors: 96})
+
+	// oldest events are evicted, counters are kept
+	r := l.report()
+	assert.Equal(t, []ErrorEvent{
+		{Errors: []string{ERROR_ACK, ERROR_CRC}},
+		{State: STATE_WARNING, TxErrors: 96},
+	}, r.Events)
+	assert.Equal(t, map[string]int{ERROR_ACK: 2, ERROR_CRC: 1, STATE_WARNING: 1}, r.Counters)
+	assert.Equal(t, 1, r.Dropped)
+
+	l.clear()
+	r = l.report()
+	assert.Empty(t, r.Events)
+	assert.Empty(t, r.Counters)
+	assert.Equal(t, 0, r.Dropped)
+}
+
+func TestDispatchErrorFrame(t *testing.T) {
+	b := &Backend{timeout: DefaultTimeout, errlog: newErrorLog(DefaultErrorLogSize)}
+
+	b.dispatch([]byte("e2as\r"))
+	r, _ := b.GetErrors()
+	assert.Len(t, r.Events, 1)
+	assert.Equal(t, []string{ERROR_ACK, ERROR_STUFF}, r.Events[0].Errors)
+	assert.False(t, r.Events[0].ReceivedAt.IsZero())
+}
@@ -0,0 +1,400 @@
+package slcansvc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrDatabaseInvalidRecord = errors.New("Database: invalid log record")
+	ErrDatabaseLogVersion    = errors.New("Database: unsupported log version")
+)
+
+// errTornRecord tells a record cut short or corrupted, as left by a crash
+var errTornRecord = errors.New("Database: torn log record")
+
+// compactRecords is the least number of records in a log before it is
+// compacted
+const compactRecords = 1024
+
+// logVersion is the format of the records written, given by the version
+// record heading the log
+const logVersion = 1
+
+const (
+	recordVersion  = "version"
+	recordTx       = "tx"
+	recordTxDelete = "tx-delete"
+	recordRx       = "rx"
+	recordRxCache  = "rx-cache"
+	recordRxDelete = "rx-delete"
+)
+
+// FileDatabase is a Database held in memory and recorded in an append-only
+// log, restored on restart. Every record is written as it happens, so that
+// the service being killed loses nothing, and carries a checksum, so that
+// the records torn by a crash are dropped on recovery. The log is compacted
+// in the background once it holds more than twice the records needed to
+// restore it.
+type FileDatabase struct {
+	*MemoryDatabase
+
+	path string
+	// mtx orders the records as the changes they log
+	mtx     sync.Mutex
+	f       *os.File
+	records int
+
+	// Records appended while a compaction runs, carried over to the log it
+	// rewrites, and done closed once it is over, nil when none runs
+	carry [][]byte
+	done  chan struct{}
+}
+
+// fileRecord is a change to the database: a message of the TX table set
+// under ID or removed, a frame received at At, the latest frame of a CAN ID
+// restored to the RX cache without its history, or its removal. The log is
+// headed by a record of its format version V.
+type fileRecord struct {
+	Op string          `json:"op"`
+	V  int             `json:"v,omitempty"`
+	ID uint32          `json:"id"`
+	At *time.Time      `json:"at,omitempty"`
+	M  json.RawMessage `json:"m,omitempty"`
+}
+
+// fileMessage is the schema of the messages in the log, apart from the JSON
+// of the API so that its encodings never reach the log: data is held as
+// base64, and the direction is set back by the database on replay.
+type fileMessage struct {
+	ID         uint32    `json:"id"`
+	Data       []byte    `json:"data,omitempty"`
+	Extended   bool      `json:"extended,omitempty"`
+	RTR        bool      `json:"rtr,omitempty"`
+	DLC        int       `json:"dlc,omitempty"`
+	FD         bool      `json:"fd,omitempty"`
+	BRS        bool      `json:"brs,omitempty"`
+	Timestamp  *uint16   `json:"timestamp,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+func encodeMessage(m Message) json.RawMessage {
+	b, _ := json.Marshal(fileMessage{ID: m.ID, Data: m.Data, Extended: m.Extended, RTR: m.RTR, DLC: m.DLC,
+		FD: m.FD, BRS: m.BRS, Timestamp: m.Timestamp, ReceivedAt: m.ReceivedAt})
+	return b
+}
+
+func decodeMessage(b json.RawMessage) (Message, error) {
+	var f fileMessage
+	if err := json.Unmarshal(b, &f); err != nil {
+		return Message{}, ErrDatabaseInvalidRecord
+	}
+	if len(f.Data) == 0 {
+		f.Data = nil
+	}
+	return Message{ID: f.ID, Data: f.Data, Extended: f.Extended, RTR: f.RTR, DLC: f.DLC,
+		FD: f.FD, BRS: f.BRS, Timestamp: f.Timestamp, ReceivedAt: f.ReceivedAt}, nil
+}
+
+// OpenFileDatabase opens the database logged to path, created if missing.
+// The log is replayed up to the first record torn or corrupted, and
+// truncated there. A log holding a record valid but unknown, or of another
+// version, is left alone and fails to open.
+func OpenFileDatabase(path string, options ...DatabaseOption) (Database, error) {
+	// Left over by a compaction cut short, path is still whole
+	if err := os.Remove(path + ".tmp"); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	d := &FileDatabase{MemoryDatabase: newMemoryDatabase(options...), path: path, f: f}
+	n, err := d.replay(f)
+	if err == nil {
+		err = f.Truncate(n)
+	}
+	if err == nil {
+		_, err = f.Seek(n, io.SeekStart)
+	}
+	if err == nil && n == 0 {
+		_, err = f.Write(encodeRecord(fileRecord{Op: recordVersion, V: logVersion}))
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// replay applies the records read from r, returning the offset past the
+// last record valid
+func (d *FileDatabase) replay(r io.Reader) (int64, error) {
+	var n int64
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			// Torn by a crash if not empty
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		rec, err := decodeRecord(line)
+		if err == errTornRecord {
+			return n, nil
+		} else if err != nil {
+			return n, err
+		}
+		// The version heads the log, and only the log
+		if (n == 0) != (rec.Op == recordVersion) {
+			return n, ErrDatabaseInvalidRecord
+		} else if n == 0 {
+			if rec.V != logVersion {
+				return n, ErrDatabaseLogVersion
+			}
+			n += int64(len(line))
+			continue
+		}
+		var m Message
+		if rec.M != nil {
+			if m, err = decodeMessage(rec.M); err != nil {
+				return n, err
+			}
+		}
+		switch rec.Op {
+		case recordTx:
+			d.setTx(rec.ID, m)
+		case recordTxDelete:
+			d.removeTx(rec.ID)
+		case recordRx:
+			d.write(m, *rec.At)
+		case recordRxCache:
+			d.cacheRx(m)
+		case recordRxDelete:
+			d.removeRx(rec.ID)
+		}
+		d.records += 1
+		n += int64(len(line))
+	}
+}
+
+// encodeRecord formats a record as its checksum and JSON, on one line
+func encodeRecord(rec fileRecord) []byte {
+	b, _ := json.Marshal(rec)
+	return append([]byte(fmt.Sprintf("%08x ", crc32.ChecksumIEEE(b))), append(b, '\n')...)
+}
+
+// decodeRecord parses a line of the log, failing with errTornRecord when
+// its checksum does not match, and ErrDatabaseInvalidRecord when it does
+// but the record is not understood
+func decodeRecord(line []byte) (fileRecord, error) {
+	var rec fileRecord
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	if len(line) < 9 || line[8] != ' ' {
+		return rec, errTornRecord
+	}
+	sum, err := strconv.ParseUint(string(line[:8]), 16, 32)
+	if err != nil || uint32(sum) != crc32.ChecksumIEEE(line[9:]) {
+		return rec, errTornRecord
+	}
+	if err := json.Unmarshal(line[9:], &rec); err != nil {
+		return rec, ErrDatabaseInvalidRecord
+	}
+	switch {
+	case rec.Op == recordVersion:
+	case (rec.Op == recordTx || rec.Op == recordRxCache) && rec.M != nil:
+	case rec.Op == recordRx && rec.M != nil && rec.At != nil:
+	case rec.Op == recordTxDelete || rec.Op == recordRxDelete:
+	default:
+		return rec, ErrDatabaseInvalidRecord
+	}
+	return rec, nil
+}
+
+func (d *FileDatabase) PostTx(m Message) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, err := d.MemoryDatabase.GetTx(m.ID); err == nil {
+		return ErrDatabaseAlreadyExists
+	}
+	return d.append(fileRecord{Op: recordTx, ID: m.ID, M: encodeMessage(m)}, func() { d.setTx(m.ID, m) })
+}
+
+func (d *FileDatabase) PutTx(id uint32, m Message) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, err := d.MemoryDatabase.GetTx(id); err != nil {
+		return err
+	}
+	return d.append(fileRecord{Op: recordTx, ID: id, M: encodeMessage(m)}, func() { d.setTx(id, m) })
+}
+
+func (d *FileDatabase) DeleteTx(id uint32) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, err := d.MemoryDatabase.GetTx(id); err != nil {
+		return err
+	}
+	return d.append(fileRecord{Op: recordTxDelete, ID: id}, func() { d.removeTx(id) })
+}
+
+func (d *FileDatabase) WriteData(m Message) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	now := time.Now()
+	return d.append(fileRecord{Op: recordRx, ID: m.ID, At: &now, M: encodeMessage(m)}, func() { d.write(m, now) })
+}
+
+func (d *FileDatabase) DeleteRx(id uint32) error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if _, err := d.MemoryDatabase.GetRx(id); err != nil {
+		return err
+	}
+	return d.append(fileRecord{Op: recordRxDelete, ID: id}, func() { d.removeRx(id) })
+}
+
+// Close waits for a compaction running and flushes the log to disk, the
+// database is not to be used anymore.
+func (d *FileDatabase) Close() error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.wait()
+	if err := d.f.Sync(); err != nil {
+		d.f.Close()
+		return err
+	}
+	return d.f.Close()
+}
+
+// Compact rewrites the log with the records needed to restore the
+// database, replacing it at once. A compaction already running in the
+// background is waited for first.
+func (d *FileDatabase) Compact() error {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.wait()
+	d.done = make(chan struct{})
+	return d.compact()
+}
+
+// wait releases d.mtx until a compaction running is over, d.mtx must be
+// held
+func (d *FileDatabase) wait() {
+	for d.done != nil {
+		done := d.done
+		d.mtx.Unlock()
+		<-done
+		d.mtx.Lock()
+	}
+}
+
+// append logs a change, then applies it, so that a change never logged is
+// never seen either, d.mtx must be held
+func (d *FileDatabase) append(rec fileRecord, apply func()) error {
+	b := encodeRecord(rec)
+	if _, err := d.f.Write(b); err != nil {
+		return err
+	}
+	apply()
+	d.records += 1
+	if d.done != nil {
+		d.carry = append(d.carry, b)
+	} else {
+		d.autoCompact()
+	}
+	return nil
+}
+
+// autoCompact starts compacting the log in the background once due, off
+// the way of the callers, e.g. the backend receiving frames. Should it
+// fail, the log is left whole and compacted next time. d.mtx must be held.
+func (d *FileDatabase) autoCompact() {
+	if d.records <= compactRecords || d.records <= 2*d.size() {
+		return
+	}
+	d.done = make(chan struct{})
+	go func() {
+		d.mtx.Lock()
+		defer d.mtx.Unlock()
+		if d.compact() == nil {
+			// Records carried over may have made it due again
+			d.autoCompact()
+		}
+	}()
+}
+
+// compact writes the version, the frames in history, the RX cache, then the
+// TX table, to a temporary log renamed over the log. d.mtx must be held,
+// and d.done set by the caller, and is released while the snapshot is
+// written so that changes keep being appended to the log meanwhile. These
+// are carried over to the temporary log before it is synced and renamed.
+func (d *FileDatabase) compact() error {
+	defer func() {
+		close(d.done)
+		d.done, d.carry = nil, nil
+	}()
+	tx, rx, frames := d.snapshot()
+	tmp := d.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	d.mtx.Unlock()
+	w := bufio.NewWriter(f)
+	_, _ = w.Write(encodeRecord(fileRecord{Op: recordVersion, V: logVersion}))
+	for i := range frames {
+		e := &frames[i]
+		_, _ = w.Write(encodeRecord(fileRecord{Op: recordRx, ID: e.m.ID, At: &e.at, M: encodeMessage(e.m)}))
+	}
+	for id, m := range rx {
+		_, _ = w.Write(encodeRecord(fileRecord{Op: recordRxCache, ID: id, M: encodeMessage(m)}))
+	}
+	for id, m := range tx {
+		_, _ = w.Write(encodeRecord(fileRecord{Op: recordTx, ID: id, M: encodeMessage(m)}))
+	}
+	d.mtx.Lock()
+
+	for _, b := range d.carry {
+		_, _ = w.Write(b)
+	}
+	err = w.Flush()
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = os.Rename(tmp, d.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(d.path))
+
+	// Appended to from now on, where the rewritten records end
+	d.f.Close()
+	d.f = f
+	d.records = len(frames) + len(rx) + len(tx) + len(d.carry)
+	return nil
+}
+
+// syncDir makes a rename in dir durable, where supported
+func syncDir(dir string) {
+	if f, err := os.Open(dir); err == nil {
+		_ = f.Sync()
+		f.Close()
+	}
+}
@@ -0,0 +1,206 @@
+package slcansvc
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.log")
+	d, err := OpenFileDatabase(path)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, d.PostTx(Message{ID: 0x100, Data: []byte("posted")}))
+	assert.Equal(t, nil, d.PutTx(0x100, Message{ID: 0x100, Data: []byte("put")}))
+	assert.Equal(t, nil, d.PostTx(Message{ID: 0x200}))
+	assert.Equal(t, nil, d.DeleteTx(0x200))
+	assert.Equal(t, ErrDatabaseNotFound, d.DeleteTx(0x200))
+	for _, s := range []string{"a", "b", "c"} {
+		assert.Equal(t, nil, d.WriteData(Message{ID: 0x300, Data: []byte(s)}))
+	}
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x100, Data: []byte("received")}))
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x400}))
+	assert.Equal(t, nil, d.DeleteRx(0x400))
+
+	// killed without being closed, everything written is restored
+	r, err := OpenFileDatabase(path)
+	assert.Equal(t, nil, err)
+	m, _ := r.GetTx(0x100)
+	assert.Equal(t, []byte("put"), m.Data)
+	_, err = r.GetTx(0x200)
+	assert.Equal(t, ErrDatabaseNotFound, err)
+	l, _ := r.GetHistory(0x300, time.Time{}, 0)
+	assert.Equal(t, []string{"a", "b", "c"}, historyData(l))
+	m, _ = r.GetRx(0x100)
+	assert.Equal(t, Message{ID: 0x100, Data: []byte("received"), Direction: DIRECTION_RX}, m)
+	_, err = r.GetRx(0x400)
+	assert.Equal(t, ErrDatabaseNotFound, err)
+	assert.Equal(t, 1, r.QueryTx(MessageQuery{}).Total)
+	assert.Equal(t, 2, r.QueryRx(MessageQuery{}).Total)
+	assert.Equal(t, nil, r.Close())
+	assert.Equal(t, nil, d.Close())
+}
+
+func TestFileDatabaseRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.log")
+	d, _ := OpenFileDatabase(path)
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x100, Data: []byte("a")}))
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x100, Data: []byte("b")}))
+	assert.Equal(t, nil, d.Close())
+	good, _ := os.ReadFile(path)
+
+	// a record torn by a crash, and one corrupted, are dropped along with
+	// what follows
+	for _, tail := range [][]byte{
+		encodeRecord(fileRecord{Op: recordRxDelete, ID: 0x100})[:20],
+		bytes.Replace(encodeRecord(fileRecord{Op: recordRxDelete, ID: 0x100}), []byte("256"), []byte("257"), 1),
+	} {
+		assert.Equal(t, nil, os.WriteFile(path, append(append([]byte{}, good...), tail...), 0644))
+		assert.Equal(t, nil, os.WriteFile(path+".tmp", []byte("partial"), 0644))
+		d, err := OpenFileDatabase(path)
+		assert.Equal(t, nil, err)
+		l, _ := d.GetHistory(0x100, time.Time{}, 0)
+		assert.Equal(t, []string{"a", "b"}, historyData(l))
+		_, err = os.Stat(path + ".tmp")
+		assert.True(t, os.IsNotExist(err))
+
+		// log is truncated to the last record valid and appended to
+		assert.Equal(t, nil, d.WriteData(Message{ID: 0x100, Data: []byte("c")}))
+		assert.Equal(t, nil, d.Close())
+		d, _ = OpenFileDatabase(path)
+		l, _ = d.GetHistory(0x100, time.Time{}, 0)
+		assert.Equal(t, []string{"a", "b", "c"}, historyData(l))
+		assert.Equal(t, nil, d.Close())
+	}
+}
+
+func TestFileDatabaseUnknownRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.log")
+	d, _ := OpenFileDatabase(path)
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x100, Data: []byte("a")}))
+	assert.Equal(t, nil, d.Close())
+	good, _ := os.ReadFile(path)
+
+	// records valid but not understood, followed by records valid, are
+	// never dropped
+	for _, c := range []struct {
+		log []byte
+		err error
+	}{
+		{append(append([]byte{}, good...), encodeRecord(fileRecord{Op: "merge", ID: 0x100})...), ErrDatabaseInvalidRecord},
+		{append(append([]byte{}, good...), encodeRecord(fileRecord{Op: recordRx, ID: 0x100})...), ErrDatabaseInvalidRecord},
+		{encodeRecord(fileRecord{Op: recordVersion, V: logVersion + 1}), ErrDatabaseLogVersion},
+		// the version heads every log
+		{good[len(encodeRecord(fileRecord{Op: recordVersion, V: logVersion})):], ErrDatabaseInvalidRecord},
+	} {
+		log := append(c.log, good...)
+		assert.Equal(t, nil, os.WriteFile(path, log, 0644))
+		_, err := OpenFileDatabase(path)
+		assert.Equal(t, c.err, err)
+		b, _ := os.ReadFile(path)
+		assert.Equal(t, log, b)
+	}
+}
+
+func TestFileDatabaseWriteFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.log")
+	d, _ := OpenFileDatabase(path)
+	assert.Equal(t, nil, d.PostTx(Message{ID: 0x100, Data: []byte("posted")}))
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x200, Data: []byte("a")}))
+
+	// changes failing to be logged are not applied either
+	d.(*FileDatabase).f.Close()
+	assert.NotEqual(t, nil, d.PostTx(Message{ID: 0x300}))
+	_, err := d.GetTx(0x300)
+	assert.Equal(t, ErrDatabaseNotFound, err)
+	assert.NotEqual(t, nil, d.PutTx(0x100, Message{ID: 0x100, Data: []byte("put")}))
+	m, _ := d.GetTx(0x100)
+	assert.Equal(t, []byte("posted"), m.Data)
+	assert.NotEqual(t, nil, d.DeleteTx(0x100))
+	_, err = d.GetTx(0x100)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, d.WriteData(Message{ID: 0x200, Data: []byte("b")}))
+	m, _ = d.GetRx(0x200)
+	assert.Equal(t, []byte("a"), m.Data)
+
+	// existence is checked before anything is logged
+	assert.Equal(t, ErrDatabaseAlreadyExists, d.PostTx(Message{ID: 0x100}))
+	assert.Equal(t, ErrDatabaseNotFound, d.PutTx(0x300, Message{ID: 0x300}))
+}
+
+func TestFileDatabaseCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "default.log")
+	d, _ := OpenFileDatabase(path, WithHistorySize(2))
+	assert.Equal(t, nil, d.PostTx(Message{ID: 0x100, Data: []byte("posted")}))
+	n := 3 * compactRecords
+	for i := 0; i < n; i++ {
+		assert.Equal(t, nil, d.WriteData(Message{ID: 0x200, Data: []byte(strconv.Itoa(i))}))
+	}
+
+	// log holds no more than twice the records needed once the compaction
+	// in the background is over, the records appended meanwhile included
+	assert.Eventually(t, func() bool {
+		b, _ := os.ReadFile(path)
+		return bytes.Count(b, []byte("\n")) <= compactRecords+2
+	}, time.Second, time.Millisecond)
+	r, _ := OpenFileDatabase(path, WithHistorySize(2))
+	l, _ := r.GetHistory(0x200, time.Time{}, 0)
+	assert.Equal(t, []string{strconv.Itoa(n - 2), strconv.Itoa(n - 1)}, historyData(l))
+	assert.Equal(t, nil, r.Close())
+
+	assert.Equal(t, nil, d.(*FileDatabase).Compact())
+	b, _ := os.ReadFile(path)
+	assert.Equal(t, 5, bytes.Count(b, []byte("\n")))
+	assert.True(t, bytes.HasPrefix(b, encodeRecord(fileRecord{Op: recordVersion, V: logVersion})))
+
+	r, _ = OpenFileDatabase(path, WithHistorySize(2))
+	m, _ := r.GetTx(0x100)
+	assert.Equal(t, []byte("posted"), m.Data)
+	l, _ = r.GetHistory(0x200, time.Time{}, 0)
+	assert.Equal(t, []string{strconv.Itoa(n - 2), strconv.Itoa(n - 1)}, historyData(l))
+	assert.Equal(t, d.GetHistoryStats().Entries, r.GetHistoryStats().Entries)
+	assert.Equal(t, nil, r.Close())
+	assert.Equal(t, nil, d.Close())
+}
+
+// TestFileDatabaseKill writes frames from a child process killed on the
+// way, every frame it reported written must be restored
+func TestFileDatabaseKill(t *testing.T) {
+	if path := os.Getenv("SLCAN_FILEDB_PATH"); path != "" {
+		d, _ := OpenFileDatabase(path, WithHistorySize(0x10000))
+		for i := 0; ; i++ {
+			if d.WriteData(Message{ID: 0x100, Data: []byte(strconv.Itoa(i))}) == nil {
+				os.Stdout.WriteString(strconv.Itoa(i) + "\n")
+			}
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "default.log")
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFileDatabaseKill$")
+	cmd.Env = append(os.Environ(), "SLCAN_FILEDB_PATH="+path)
+	out, _ := cmd.StdoutPipe()
+	assert.Equal(t, nil, cmd.Start())
+	last := -1
+	for sc := bufio.NewScanner(out); sc.Scan() && last < 1000; {
+		last, _ = strconv.Atoi(sc.Text())
+	}
+	assert.Equal(t, 1000, last)
+	assert.Equal(t, nil, cmd.Process.Kill())
+	_ = cmd.Wait()
+
+	d, err := OpenFileDatabase(path, WithHistorySize(0x10000))
+	assert.Equal(t, nil, err)
+	l, _ := d.GetHistory(0x100, time.Time{}, 0)
+	assert.True(t, len(l) > last, len(l))
+	for i, m := range l {
+		assert.Equal(t, []byte(strconv.Itoa(i)), m.Data)
+	}
+	assert.Equal(t, nil, d.Close())
+}
@@ -0,0 +1,85 @@
+package slcansvc
+
+import (
+	"encoding/hex"
+)
+
+// Acceptance is the SJA1000 style acceptance code and mask programmed into
+// the SLCAN device with the "M" and "m" commands, as 8 hex digits each.
+// A zero Acceptance leaves the device at its firmware default.
+type Acceptance struct {
+	Code string `json:"code" example:"00000000"`
+	Mask string `json:"mask" example:"ffffffff"`
+}
+
+// Filter accepts received frames whose ID lies within From and To, and
+// equals ID in the bits set in Mask. A zero To leaves the range unbounded
+// and a zero Mask matches any ID. Type restricts the filter to standard
+// ("std") or extended ("ext") frames.
+type Filter struct {
+	From uint32 `json:"from" example:"256"`
+	To   uint32 `json:"to" example:"511"`
+	ID   uint32 `json:"id" example:"0"`
+	Mask uint32 `json:"mask" example:"0"`
+	Type string `json:"type,omitempty" example:"std"`
+}
+
+const (
+	FILTER_STANDARD = "std"
+	FILTER_EXTENDED = "ext"
+)
+
+func (f Filter) validate() error {
+	if f.Type != "" && f.Type != FILTER_STANDARD && f.Type != FILTER_EXTENDED {
+		return ErrBackendInvalidFilter
+	}
+	if f.From > CAN_ID_MAX || f.To > CAN_ID_MAX || f.ID > CAN_ID_MAX || f.Mask > CAN_ID_MAX {
+		return ErrBackendInvalidFilter
+	}
+	if f.To != 0 && f.To < f.From {
+		return ErrBackendInvalidFilter
+	}
+	return nil
+}
+
+func (f Filter) match(m Message) bool {
+	ext := m.Extended || m.ID > 0x7ff
+	if (f.Type == FILTER_STANDARD && ext) || (f.Type == FILTER_EXTENDED && !ext) {
+		return false
+	}
+	if m.ID < f.From || (f.To != 0 && m.ID > f.To) {
+		return false
+	}
+	return m.ID&f.Mask == f.ID&f.Mask
+}
+
+// acceptFrame reports whether any of the filters matches the frame, an
+// empty filter list accepts every frame
+func acceptFrame(filters []Filter, m Message) bool {
+	if len(filters) == 0 {
+		return true
+	}
+	for _, f := range filters {
+		if f.match(m) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptanceSeq returns the commands which program the acceptance code and
+// mask into the SLCAN device
+func acceptanceSeq(a Acceptance) ([][]byte, error) {
+	for _, r := range []string{a.Code, a.Mask} {
+		if len(r) != 8 {
+			return nil, ErrBackendInvalidFilter
+		}
+		if _, err := hex.DecodeString(r); err != nil {
+			return nil, ErrBackendInvalidFilter
+		}
+	}
+	return [][]byte{
+		[]byte("M" + a.Code + "\r"),
+		[]byte("m" + a.Mask + "\r"),
+	}, nil
+}
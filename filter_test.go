@@ -0,0 +1,74 @@
+package slcansvc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAcceptFrame(t *testing.T) {
+	// no filters accept every frame
+	assert.True(t, acceptFrame(nil, Message{ID: 0x123}))
+
+	// ID range
+	f := []Filter{{From: 0x100, To: 0x1ff}}
+	assert.True(t, acceptFrame(f, Message{ID: 0x100}))
+	assert.True(t, acceptFrame(f, Message{ID: 0x1ff}))
+	assert.False(t, acceptFrame(f, Message{ID: 0x200}))
+
+	// ID mask
+	f = []Filter{{ID: 0x120, Mask: 0x7f0}}
+	assert.True(t, acceptFrame(f, Message{ID: 0x12f}))
+	assert.False(t, acceptFrame(f, Message{ID: 0x130}))
+
+	// standard and extended frames
+	f = []Filter{{Type: FILTER_EXTENDED}}
+	assert.True(t, acceptFrame(f, Message{ID: 0x123, Extended: true}))
+	assert.True(t, acceptFrame(f, Message{ID: 0x12345678}))
+	assert.False(t, acceptFrame(f, Message{ID: 0x123}))
+
+	// any filter matching accepts the frame
+	f = []Filter{{From: 0x100, To: 0x1ff, Type: FILTER_STANDARD}, {ID: 0x12345678, Mask: CAN_ID_MAX}}
+	assert.True(t, acceptFrame(f, Message{ID: 0x123}))
+	assert.True(t, acceptFrame(f, Message{ID: 0x12345678}))
+	assert.False(t, acceptFrame(f, Message{ID: 0x123, Extended: true}))
+}
+
+func TestFilterValidate(t *testing.T) {
+	assert.Equal(t, nil, Filter{From: 0x100, To: 0x1ff, Type: FILTER_STANDARD}.validate())
+	assert.NotEqual(t, nil, Filter{From: 0x1ff, To: 0x100}.validate())
+	assert.NotEqual(t, nil, Filter{Type: "fd"}.validate())
+	assert.NotEqual(t, nil, Filter{Mask: 0x20000000}.validate())
+}
+
+func TestAcceptanceSequence(t *testing.T) {
+	s, err := acceptanceSeq(Acceptance{Code: "12345678", Mask: "00000000"})
+	assert.Equal(t, [][]byte{[]byte("M12345678\r"), []byte("m00000000\r")}, s)
+	assert.Equal(t, nil, err)
+
+	s, err = acceptanceSeq(Acceptance{Code: "1234", Mask: "00000000"})
+	assert.Empty(t, s)
+	assert.NotEqual(t, nil, err)
+
+	s, err = acceptanceSeq(Acceptance{Code: "12345678", Mask: "0000000g"})
+	assert.Empty(t, s)
+	assert.NotEqual(t, nil, err)
+}
+
+func TestSetFilters(t *testing.T) {
+	b := NewBackend(NewDatabase(), WithFilters([]Filter{{From: 0x100, To: 0x1ff}}))
+	f, err := b.GetFilters()
+	assert.Equal(t, []Filter{{From: 0x100, To: 0x1ff}}, f)
+	assert.Equal(t, nil, err)
+
+	// invalid filters leave the list untouched
+	err = b.SetFilters([]Filter{{From: 0x100}, {Type: "fd"}})
+	assert.NotEqual(t, nil, err)
+	f, _ = b.GetFilters()
+	assert.Equal(t, []Filter{{From: 0x100, To: 0x1ff}}, f)
+
+	err = b.SetFilters(nil)
+	assert.Equal(t, nil, err)
+	f, _ = b.GetFilters()
+	assert.Empty(t, f)
+}
@@ -0,0 +1,229 @@
+package slcansvc
+
+import (
+	"container/heap"
+	"sort"
+	"time"
+	"unsafe"
+)
+
+// DefaultHistorySize is the default number of frames kept per CAN ID.
+const DefaultHistorySize = 100
+
+// DefaultHistoryMemory is the default limit in bytes on the frames kept
+// by a database.
+const DefaultHistoryMemory = 64 << 20
+
+// HistoryStats reports the frames kept in the history and those evicted,
+// by reason.
+type HistoryStats struct {
+	IDs     int `json:"ids" example:"12"`
+	Entries int `json:"entries" example:"1200"`
+	// Estimated memory used by the frames kept, and its limit
+	Bytes int `json:"bytes" example:"201600"`
+	Limit int `json:"limit" example:"67108864"`
+	// Frames evicted as the ring of their CAN ID was full, as they grew
+	// older than the age limit, or to stay within the memory limit
+	EvictedSize   int `json:"evicted_size" example:"3400"`
+	EvictedAge    int `json:"evicted_age" example:"0"`
+	EvictedMemory int `json:"evicted_memory" example:"0"`
+}
+
+// history keeps the most recent frames of every CAN ID in rings of size
+// frames, younger than age, evicting the oldest frames of all CAN IDs once
+// above limit bytes. Zero age and limit are unlimited, zero size disables
+// the history.
+type history struct {
+	size  int
+	age   time.Duration
+	limit int
+
+	seq   uint64
+	rings map[uint32]*historyRing
+	// Rings ordered by their oldest frame, for global eviction
+	oldest historyHeap
+	stats  HistoryStats
+}
+
+type historyEntry struct {
+	seq uint64
+	at  time.Time
+	m   Message
+}
+
+// historyRing holds the frames of a CAN ID, oldest first, growing up to
+// the history size. index is its position in the history heap.
+type historyRing struct {
+	id    uint32
+	buf   []historyEntry
+	index int
+}
+
+func newHistory(size int, age time.Duration, limit int) *history {
+	return &history{
+		size:  size,
+		age:   age,
+		limit: limit,
+		rings: make(map[uint32]*historyRing),
+	}
+}
+
+// historyEntrySize estimates the memory used by a frame in the history
+func historyEntrySize(m Message) int {
+	n := int(unsafe.Sizeof(historyEntry{})) + len(m.Data)
+	if m.Timestamp != nil {
+		n += int(unsafe.Sizeof(*m.Timestamp))
+	}
+	return n
+}
+
+// add records a frame, evicting frames beyond the limits
+func (h *history) add(m Message, now time.Time) {
+	if h.size <= 0 {
+		return
+	}
+	h.seq += 1
+	e := historyEntry{seq: h.seq, at: now, m: m}
+	r, ok := h.rings[m.ID]
+	switch {
+	case !ok:
+		r = &historyRing{id: m.ID, buf: []historyEntry{e}}
+		h.rings[m.ID] = r
+		heap.Push(&h.oldest, r)
+		h.stats.IDs += 1
+	case len(r.buf) == h.size:
+		// Ring is full, the oldest frame makes room
+		h.stats.Entries -= 1
+		h.stats.Bytes -= historyEntrySize(r.buf[0].m)
+		h.stats.EvictedSize += 1
+		r.buf[0] = historyEntry{}
+		r.buf = append(r.buf[1:], e)
+		heap.Fix(&h.oldest, r.index)
+	default:
+		r.buf = append(r.buf, e)
+	}
+	h.stats.Entries += 1
+	h.stats.Bytes += historyEntrySize(m)
+
+	h.expire(now)
+	for h.limit > 0 && h.stats.Bytes > h.limit && len(h.oldest) > 0 {
+		h.evict(h.oldest[0])
+		h.stats.EvictedMemory += 1
+	}
+}
+
+// expire evicts the frames older than the age limit
+func (h *history) expire(now time.Time) {
+	if h.age <= 0 {
+		return
+	}
+	cutoff := now.Add(-h.age)
+	for len(h.oldest) > 0 {
+		r := h.oldest[0]
+		if !r.buf[0].at.Before(cutoff) {
+			return
+		}
+		h.evict(r)
+		h.stats.EvictedAge += 1
+	}
+}
+
+// evict drops the oldest frame of a ring, and the ring once empty
+func (h *history) evict(r *historyRing) {
+	h.stats.Entries -= 1
+	h.stats.Bytes -= historyEntrySize(r.buf[0].m)
+	r.buf[0] = historyEntry{}
+	r.buf = r.buf[1:]
+	if len(r.buf) > 0 {
+		heap.Fix(&h.oldest, r.index)
+		return
+	}
+	heap.Remove(&h.oldest, r.index)
+	delete(h.rings, r.id)
+	h.stats.IDs -= 1
+}
+
+// remove drops every frame of a CAN ID
+func (h *history) remove(id uint32) {
+	r, ok := h.rings[id]
+	if !ok {
+		return
+	}
+	for _, e := range r.buf {
+		h.stats.Bytes -= historyEntrySize(e.m)
+	}
+	h.stats.Entries -= len(r.buf)
+	h.stats.IDs -= 1
+	heap.Remove(&h.oldest, r.index)
+	delete(h.rings, id)
+}
+
+// query returns the frames of a CAN ID in the order received. Given since,
+// the first limit frames received after since are returned, otherwise the
+// last limit frames. Zero limit returns every frame.
+func (h *history) query(id uint32, since time.Time, limit int, now time.Time) ([]Message, bool) {
+	h.expire(now)
+	r, ok := h.rings[id]
+	if !ok {
+		return nil, false
+	}
+	l := make([]Message, 0, len(r.buf))
+	for _, e := range r.buf {
+		l = append(l, e.m)
+	}
+	if !since.IsZero() {
+		i := sort.Search(len(l), func(i int) bool { return l[i].ReceivedAt.After(since) })
+		l = l[i:]
+		if limit > 0 && len(l) > limit {
+			l = l[:limit]
+		}
+	} else if limit > 0 && len(l) > limit {
+		l = l[len(l)-limit:]
+	}
+	return l, true
+}
+
+// entries returns every frame kept, in the order received
+func (h *history) entries() []historyEntry {
+	l := make([]historyEntry, 0, h.stats.Entries)
+	for _, r := range h.rings {
+		l = append(l, r.buf...)
+	}
+	sort.Slice(l, func(i, j int) bool { return l[i].seq < l[j].seq })
+	return l
+}
+
+func (h *history) report(now time.Time) HistoryStats {
+	h.expire(now)
+	s := h.stats
+	s.Limit = h.limit
+	return s
+}
+
+// historyHeap orders rings by their oldest frame
+type historyHeap []*historyRing
+
+func (q historyHeap) Len() int { return len(q) }
+
+func (q historyHeap) Less(i, j int) bool {
+	return q[i].buf[0].seq < q[j].buf[0].seq
+}
+
+func (q historyHeap) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *historyHeap) Push(x interface{}) {
+	r := x.(*historyRing)
+	r.index = len(*q)
+	*q = append(*q, r)
+}
+
+func (q *historyHeap) Pop() interface{} {
+	old := *q
+	r := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return r
+}
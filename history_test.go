@@ -0,0 +1,125 @@
+package slcansvc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	h := newHistory(3, 0, 0)
+	t0 := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		at := t0.Add(time.Duration(i) * time.Second)
+		h.add(Message{ID: 0x100, Data: []byte{byte('a' + i)}, ReceivedAt: at}, at)
+	}
+
+	// ring keeps the last frames, oldest first
+	l, ok := h.query(0x100, time.Time{}, 0, t0)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"c", "d", "e"}, historyData(l))
+	l, _ = h.query(0x100, time.Time{}, 2, t0)
+	assert.Equal(t, []string{"d", "e"}, historyData(l))
+
+	// frames after since, up to limit
+	l, _ = h.query(0x100, t0.Add(2*time.Second), 0, t0)
+	assert.Equal(t, []string{"d", "e"}, historyData(l))
+	l, _ = h.query(0x100, t0.Add(time.Second), 1, t0)
+	assert.Equal(t, []string{"c"}, historyData(l))
+	l, _ = h.query(0x100, t0.Add(time.Minute), 0, t0)
+	assert.Equal(t, []string{}, historyData(l))
+
+	_, ok = h.query(0x200, time.Time{}, 0, t0)
+	assert.False(t, ok)
+
+	st := h.report(t0)
+	assert.Equal(t, 1, st.IDs)
+	assert.Equal(t, 3, st.Entries)
+	assert.Equal(t, 2, st.EvictedSize)
+	assert.Equal(t, 3*historyEntrySize(Message{Data: []byte("a")}), st.Bytes)
+
+	h.remove(0x100)
+	st = h.report(t0)
+	assert.Equal(t, 0, st.IDs)
+	assert.Equal(t, 0, st.Entries)
+	assert.Equal(t, 0, st.Bytes)
+}
+
+func TestHistoryAge(t *testing.T) {
+	h := newHistory(10, time.Second, 0)
+	t0 := time.Now()
+	h.add(Message{ID: 0x100}, t0)
+	h.add(Message{ID: 0x200}, t0.Add(500*time.Millisecond))
+	h.add(Message{ID: 0x100}, t0.Add(time.Second))
+
+	// frames grown too old are evicted on the way
+	h.add(Message{ID: 0x300}, t0.Add(1200*time.Millisecond))
+	l, _ := h.query(0x100, time.Time{}, 0, t0.Add(1200*time.Millisecond))
+	assert.Equal(t, 1, len(l))
+	_, ok := h.query(0x200, time.Time{}, 0, t0.Add(1600*time.Millisecond))
+	assert.False(t, ok)
+	assert.Equal(t, 2, h.report(t0.Add(1600*time.Millisecond)).EvictedAge)
+}
+
+func TestHistoryMemory(t *testing.T) {
+	n := historyEntrySize(Message{})
+	h := newHistory(10, 0, 4*n)
+	now := time.Now()
+	for _, id := range []uint32{0x100, 0x200, 0x100, 0x300} {
+		h.add(Message{ID: id}, now)
+	}
+
+	// oldest frames of all CAN IDs are evicted first
+	h.add(Message{ID: 0x300}, now)
+	h.add(Message{ID: 0x300}, now)
+	_, ok := h.query(0x200, time.Time{}, 0, now)
+	assert.False(t, ok)
+	l, _ := h.query(0x100, time.Time{}, 0, now)
+	assert.Equal(t, 1, len(l))
+	l, _ = h.query(0x300, time.Time{}, 0, now)
+	assert.Equal(t, 3, len(l))
+
+	st := h.report(now)
+	assert.Equal(t, HistoryStats{IDs: 2, Entries: 4, Bytes: 4 * n, Limit: 4 * n, EvictedMemory: 2}, st)
+}
+
+func TestDatabaseHistory(t *testing.T) {
+	d := NewDatabase(WithHistorySize(2))
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x100, Data: []byte("a")}))
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x100, Data: []byte("b")}))
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x100, Data: []byte("c")}))
+
+	// latest message is still at hand
+	m, _ := d.GetRx(0x100)
+	assert.Equal(t, []byte("c"), m.Data)
+	l, err := d.GetHistory(0x100, time.Time{}, 0)
+	assert.Equal(t, []string{"b", "c"}, historyData(l))
+	assert.Equal(t, nil, err)
+
+	// frames no longer in history leave the latest one in the RX cache,
+	// messages posted for transmission and unknown ones are not found
+	d = NewDatabase(WithHistorySize(0))
+	assert.Equal(t, nil, d.WriteData(Message{ID: 0x100, Data: []byte("a")}))
+	l, err = d.GetHistory(0x100, time.Time{}, 0)
+	assert.Equal(t, []Message{}, l)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, d.PostTx(Message{ID: 0x200}))
+	_, err = d.GetHistory(0x200, time.Time{}, 0)
+	assert.Equal(t, ErrDatabaseNotFound, err)
+	_, err = d.GetHistory(0x300, time.Time{}, 0)
+	assert.Equal(t, ErrDatabaseNotFound, err)
+
+	assert.Equal(t, nil, d.DeleteRx(0x100))
+	_, err = d.GetHistory(0x100, time.Time{}, 0)
+	assert.Equal(t, ErrDatabaseNotFound, err)
+	assert.Equal(t, 0, d.GetHistoryStats().Entries)
+}
+
+func historyData(l []Message) []string {
+	d := []string{}
+	for _, m := range l {
+		d = append(d, string(m.Data))
+	}
+	return d
+}
@@ -0,0 +1,245 @@
+package slcansvc
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tarm/serial"
+)
+
+var (
+	ErrLinkClosed = errors.New("Link: link is not open")
+	ErrLinkDown   = errors.New("Link: connection lost")
+)
+
+// Link is the byte stream connecting the backend to the SLCAN device. Read
+// returns no data rather than blocking indefinitely when the device is
+// idle, so that the backend keeps serving requests.
+type Link interface {
+	// Open connects to the device at the given port and baudrate
+	Open(port string, baud int) error
+	Read(p []byte) (int, error)
+	Write(p []byte) (int, error)
+	Close() error
+	// Flush discards data received but not yet read
+	Flush() error
+	// Reconfigure changes the baudrate of an open link
+	Reconfigure(baud int) error
+}
+
+// NewLink returns the Link for a port, a TCP link for "tcp://host:port",
+// a simulated device for "sim://" and a serial port link otherwise.
+func NewLink(port string) Link {
+	switch {
+	case strings.HasPrefix(port, TCP_SCHEME):
+		return NewTCPLink()
+	case strings.HasPrefix(port, SIM_SCHEME):
+		return NewSimulator()
+	default:
+		return NewSerialLink()
+	}
+}
+
+// serialLink is a Link over a serial port, e.g. /dev/ttyACM0. Symlinks
+// such as /dev/serial/by-id/... are resolved whenever the port is opened,
+// following the device when it enumerates under another name.
+type serialLink struct {
+	mtx  sync.Mutex
+	name string
+	conf serial.Config
+	port *serial.Port
+}
+
+// NewSerialLink returns a Link over a serial port.
+func NewSerialLink() Link {
+	return &serialLink{}
+}
+
+func (l *serialLink) Open(port string, baud int) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	// Ports which are not paths, e.g. COM3, are opened as they are
+	name := port
+	if n, err := filepath.EvalSymlinks(port); err == nil {
+		name = n
+	}
+	l.name = port
+	l.conf = serial.Config{Name: name, Baud: baud, ReadTimeout: time.Second}
+	p, err := serial.OpenPort(&l.conf)
+	if err != nil {
+		return err
+	}
+	l.port = p
+	return nil
+}
+
+// get returns the open port and its device name
+func (l *serialLink) get() (*serial.Port, string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.port, l.conf.Name
+}
+
+func (l *serialLink) Read(p []byte) (int, error) {
+	port, name := l.get()
+	if port == nil {
+		return 0, ErrLinkClosed
+	}
+	n, err := port.Read(p)
+	if err == io.EOF {
+		// Reads time out on an idle port, unless the device has gone away
+		if _, err := os.Stat(name); err != nil {
+			return 0, ErrLinkDown
+		}
+		return n, nil
+	}
+	return n, err
+}
+
+func (l *serialLink) Write(p []byte) (int, error) {
+	port, _ := l.get()
+	if port == nil {
+		return 0, ErrLinkClosed
+	}
+	return port.Write(p)
+}
+
+func (l *serialLink) Close() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.port == nil {
+		return ErrLinkClosed
+	}
+	err := l.port.Close()
+	l.port = nil
+	return err
+}
+
+func (l *serialLink) Flush() error {
+	port, _ := l.get()
+	if port == nil {
+		return ErrLinkClosed
+	}
+	return port.Flush()
+}
+
+// Reconfigure reopens the serial port, as tarm/serial cannot change the
+// baudrate of an open port
+func (l *serialLink) Reconfigure(baud int) error {
+	if err := l.Close(); err != nil {
+		return err
+	}
+	return l.Open(l.name, baud)
+}
+
+const TCP_SCHEME = "tcp://"
+
+const (
+	tcpDialTimeout  = 5 * time.Second
+	tcpKeepAlive    = 10 * time.Second
+	tcpReadTimeout  = 100 * time.Millisecond
+	tcpFlushTimeout = 10 * time.Millisecond
+)
+
+// tcpLink is a Link over TCP to a serial bridge such as ser2net, e.g.
+// tcp://192.168.1.10:3333. TCP keepalives detect a bridge gone silent, and
+// a lost connection fails the link for the backend to reconnect.
+type tcpLink struct {
+	mtx  sync.Mutex
+	conn net.Conn
+}
+
+// NewTCPLink returns a Link over TCP, the baudrate is left to the bridge.
+func NewTCPLink() Link {
+	return &tcpLink{}
+}
+
+func (l *tcpLink) Open(port string, baud int) error {
+	d := net.Dialer{Timeout: tcpDialTimeout, KeepAlive: tcpKeepAlive}
+	c, err := d.Dial("tcp", strings.TrimPrefix(port, TCP_SCHEME))
+	if err != nil {
+		return err
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.conn = c
+	return nil
+}
+
+func (l *tcpLink) get() net.Conn {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.conn
+}
+
+func (l *tcpLink) Read(p []byte) (int, error) {
+	c := l.get()
+	if c == nil {
+		return 0, ErrLinkClosed
+	}
+	_ = c.SetReadDeadline(time.Now().Add(tcpReadTimeout))
+	n, err := c.Read(p)
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		return n, nil
+	}
+	if err != nil {
+		return n, ErrLinkDown
+	}
+	return n, nil
+}
+
+func (l *tcpLink) Write(p []byte) (int, error) {
+	c := l.get()
+	if c == nil {
+		return 0, ErrLinkClosed
+	}
+	n, err := c.Write(p)
+	if err != nil {
+		return n, ErrLinkDown
+	}
+	return n, nil
+}
+
+func (l *tcpLink) Close() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.conn == nil {
+		return ErrLinkClosed
+	}
+	err := l.conn.Close()
+	l.conn = nil
+	return err
+}
+
+// Flush discards whatever the bridge has already sent
+func (l *tcpLink) Flush() error {
+	c := l.get()
+	if c == nil {
+		return ErrLinkClosed
+	}
+	rb := make([]byte, 256)
+	for {
+		_ = c.SetReadDeadline(time.Now().Add(tcpFlushTimeout))
+		_, err := c.Read(rb)
+		if e, ok := err.(net.Error); ok && e.Timeout() {
+			return nil
+		}
+		if err != nil {
+			return ErrLinkDown
+		}
+	}
+}
+
+// Reconfigure has nothing to do, the bridge owns the serial port settings
+func (l *tcpLink) Reconfigure(baud int) error {
+	if l.get() == nil {
+		return ErrLinkClosed
+	}
+	return nil
+}
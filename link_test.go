@@ -0,0 +1,182 @@
+package slcansvc
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeReply is the response of a SLCAN device to a command or frame
+func fakeReply(p []byte) string {
+	switch p[0] {
+	case 't', 'T', 'r', 'R', 'd', 'D', 'b', 'B':
+		return "z\r"
+	case 'V':
+		return "V1013\r"
+	case 'N':
+		return "NA123\r"
+	case 'v':
+		return "\a"
+	default:
+		return "\r"
+	}
+}
+
+// fakeLink answers SLCAN commands the way a device would, and records
+// everything written to it
+type fakeLink struct {
+	mtx     sync.Mutex
+	open    bool
+	rx      bytes.Buffer
+	written bytes.Buffer
+}
+
+func (l *fakeLink) Open(port string, baud int) error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.open = true
+	return nil
+}
+
+func (l *fakeLink) Read(p []byte) (int, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if !l.open {
+		return 0, ErrLinkClosed
+	}
+	if l.rx.Len() == 0 {
+		// Behave like an idle serial port with a read timeout
+		l.mtx.Unlock()
+		time.Sleep(time.Millisecond)
+		l.mtx.Lock()
+		return 0, nil
+	}
+	return l.rx.Read(p)
+}
+
+func (l *fakeLink) Write(p []byte) (int, error) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if !l.open {
+		return 0, ErrLinkClosed
+	}
+	l.written.Write(p)
+	l.rx.WriteString(fakeReply(p))
+	return len(p), nil
+}
+
+func (l *fakeLink) Close() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.open = false
+	return nil
+}
+
+func (l *fakeLink) Flush() error {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.rx.Reset()
+	return nil
+}
+
+func (l *fakeLink) Reconfigure(baud int) error { return nil }
+
+// receive injects a line as if sent by the device
+func (l *fakeLink) receive(s string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	l.rx.WriteString(s)
+}
+
+func (l *fakeLink) sent() string {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return l.written.String()
+}
+
+func TestHandlerLink(t *testing.T) {
+	l := &fakeLink{}
+	db := NewDatabase()
+	b := NewBackend(db, WithLink(l), WithStatusInterval(0), WithBitrate(Bitrate{Rate: 500000}))
+	go b.Handler("fake", 115200, "")
+
+	// channel is opened and the device queried
+	assert.Eventually(t, func() bool {
+		d, _ := b.GetDeviceInfo()
+		return d.Serial == "A123"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, "C\rS6\rO\rV\rv\rN\r", l.sent())
+	d, _ := b.GetDeviceInfo()
+	assert.Equal(t, "10", d.Hardware)
+	assert.Equal(t, "", d.Version)
+
+	// transmitted frames are confirmed by the device
+	assert.Equal(t, nil, b.PostMessage(Message{ID: 0x123, Data: []byte("AB")}))
+	assert.Contains(t, l.sent(), "t12324142\r")
+
+	// received frames are stored
+	l.receive("t3213414243\r")
+	assert.Eventually(t, func() bool {
+		m, err := db.GetRx(0x321)
+		return err == nil && string(m.Data) == "ABC"
+	}, time.Second, time.Millisecond)
+}
+
+// fakeAdapter is a serial bridge answering SLCAN commands over TCP, the
+// connections accepted are sent to conns
+func fakeAdapter(t *testing.T, conns chan net.Conn) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Equal(t, nil, err)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- c
+			go func() {
+				r := bufio.NewReader(c)
+				for {
+					l, err := r.ReadBytes('\r')
+					if err != nil {
+						return
+					}
+					// Frames are followed by NUL, left behind by the line read
+					l = bytes.TrimLeft(l, "\x00")
+					if _, err := c.Write([]byte(fakeReply(l))); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln
+}
+
+func TestTCPLink(t *testing.T) {
+	conns := make(chan net.Conn, 4)
+	ln := fakeAdapter(t, conns)
+	defer ln.Close()
+
+	b := NewBackend(NewDatabase(), WithStatusInterval(0))
+	go b.Handler(TCP_SCHEME+ln.Addr().String(), 0, "")
+
+	// channel is opened and the device queried over TCP
+	assert.Eventually(t, func() bool {
+		d, _ := b.GetDeviceInfo()
+		return d.Serial == "A123"
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, nil, b.PostMessage(Message{ID: 0x123, Data: []byte("AB")}))
+
+	// bridge drops the connection, which is redialled
+	(<-conns).Close()
+	assert.Eventually(t, func() bool {
+		return b.PostMessage(Message{ID: 0x123, Data: []byte("AB")}) == nil
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Len(t, conns, 1)
+}
@@ -24,32 +24,81 @@ type loggingMiddleware struct {
 	logger log.Logger
 }
 
-func (mw loggingMiddleware) GetMessage(ctx context.Context, id int) (m Message, err error) {
+func (mw loggingMiddleware) ListTxMessages(ctx context.Context, q MessageQuery) (l MessageList, err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log("method", "GetMessage", "id", id, "took", time.Since(begin), "err", err)
+		mw.logger.Log("method", "ListTxMessages", "from", q.From, "to", q.To, "offset", q.Offset, "limit", q.Limit, "total", l.Total, "took", time.Since(begin), "err", err)
 	}(time.Now())
-	return mw.next.GetMessage(ctx, id)
+	return mw.next.ListTxMessages(ctx, q)
 }
 
-func (mw loggingMiddleware) PostMessage(ctx context.Context, m Message) (err error) {
+func (mw loggingMiddleware) GetTxMessage(ctx context.Context, id int) (m Message, err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log("method", "PostMessage", "id", m.ID, "took", time.Since(begin), "err", err)
+		mw.logger.Log("method", "GetTxMessage", "id", id, "took", time.Since(begin), "err", err)
 	}(time.Now())
-	return mw.next.PostMessage(ctx, m)
+	return mw.next.GetTxMessage(ctx, id)
 }
 
-func (mw loggingMiddleware) PutMessage(ctx context.Context, id int, m Message) (err error) {
+func (mw loggingMiddleware) PostTxMessage(ctx context.Context, m Message) (err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log("method", "PutMessage", "id", id, "took", time.Since(begin), "err", err)
+		mw.logger.Log("method", "PostTxMessage", "id", m.ID, "took", time.Since(begin), "err", err)
 	}(time.Now())
-	return mw.next.PutMessage(ctx, id, m)
+	return mw.next.PostTxMessage(ctx, m)
 }
 
-func (mw loggingMiddleware) DeleteMessage(ctx context.Context, id int) (err error) {
+func (mw loggingMiddleware) PutTxMessage(ctx context.Context, id int, m Message) (err error) {
 	defer func(begin time.Time) {
-		mw.logger.Log("method", "DeleteMessage", "id", id, "took", time.Since(begin), "err", err)
+		mw.logger.Log("method", "PutTxMessage", "id", id, "took", time.Since(begin), "err", err)
 	}(time.Now())
-	return mw.next.DeleteMessage(ctx, id)
+	return mw.next.PutTxMessage(ctx, id, m)
+}
+
+func (mw loggingMiddleware) DeleteTxMessage(ctx context.Context, id int) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "DeleteTxMessage", "id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.DeleteTxMessage(ctx, id)
+}
+
+func (mw loggingMiddleware) ListRxMessages(ctx context.Context, q MessageQuery) (l MessageList, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "ListRxMessages", "from", q.From, "to", q.To, "offset", q.Offset, "limit", q.Limit, "total", l.Total, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.ListRxMessages(ctx, q)
+}
+
+func (mw loggingMiddleware) GetRxMessage(ctx context.Context, id int) (m Message, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetRxMessage", "id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetRxMessage(ctx, id)
+}
+
+func (mw loggingMiddleware) DeleteRxMessage(ctx context.Context, id int) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "DeleteRxMessage", "id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.DeleteRxMessage(ctx, id)
+}
+
+func (mw loggingMiddleware) GetHistory(ctx context.Context, id int, since time.Time, limit int) (h []Message, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetHistory", "id", id, "since", since, "limit", limit, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetHistory(ctx, id, since, limit)
+}
+
+func (mw loggingMiddleware) GetHistoryStats(ctx context.Context) (st HistoryStats, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetHistoryStats", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetHistoryStats(ctx)
+}
+
+func (mw loggingMiddleware) RequestMessage(ctx context.Context, id int, r RemoteRequest) (m Message, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "RequestMessage", "id", id, "dlc", r.DLC, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.RequestMessage(ctx, id, r)
 }
 
 func (mw loggingMiddleware) Reboot(ctx context.Context) (err error) {
@@ -66,6 +115,132 @@ func (mw loggingMiddleware) Unlock(ctx context.Context) (err error) {
 	return mw.next.Unlock(ctx)
 }
 
+func (mw loggingMiddleware) GetBitrate(ctx context.Context) (br Bitrate, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetBitrate", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetBitrate(ctx)
+}
+
+func (mw loggingMiddleware) SetBitrate(ctx context.Context, br Bitrate) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "SetBitrate", "rate", br.Rate, "btr", br.BTR, "data", br.Data, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.SetBitrate(ctx, br)
+}
+
+func (mw loggingMiddleware) GetAcceptance(ctx context.Context) (a Acceptance, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetAcceptance", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetAcceptance(ctx)
+}
+
+func (mw loggingMiddleware) SetAcceptance(ctx context.Context, a Acceptance) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "SetAcceptance", "code", a.Code, "mask", a.Mask, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.SetAcceptance(ctx, a)
+}
+
+func (mw loggingMiddleware) GetFilters(ctx context.Context) (f []Filter, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetFilters", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetFilters(ctx)
+}
+
+func (mw loggingMiddleware) SetFilters(ctx context.Context, f []Filter) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "SetFilters", "filters", len(f), "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.SetFilters(ctx, f)
+}
+
+func (mw loggingMiddleware) SetMode(ctx context.Context, mode string) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "SetMode", "mode", mode, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.SetMode(ctx, mode)
+}
+
+func (mw loggingMiddleware) GetStatus(ctx context.Context) (st Status, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetStatus", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetStatus(ctx)
+}
+
+func (mw loggingMiddleware) GetDevice(ctx context.Context, refresh bool) (d DeviceInfo, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetDevice", "refresh", refresh, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetDevice(ctx, refresh)
+}
+
+func (mw loggingMiddleware) GetErrors(ctx context.Context) (r ErrorReport, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetErrors", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetErrors(ctx)
+}
+
+func (mw loggingMiddleware) ClearErrors(ctx context.Context) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "ClearErrors", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.ClearErrors(ctx)
+}
+
+func (mw loggingMiddleware) GetJobs(ctx context.Context) (j []Job, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetJobs", "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetJobs(ctx)
+}
+
+func (mw loggingMiddleware) PostJob(ctx context.Context, j Job) (r Job, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "PostJob", "id", r.ID, "can_id", j.Message.ID, "period", j.Period, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.PostJob(ctx, j)
+}
+
+func (mw loggingMiddleware) GetJob(ctx context.Context, id int) (j Job, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "GetJob", "id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.GetJob(ctx, id)
+}
+
+func (mw loggingMiddleware) PutJob(ctx context.Context, id int, j Job) (r Job, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "PutJob", "id", id, "can_id", j.Message.ID, "period", j.Period, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.PutJob(ctx, id, j)
+}
+
+func (mw loggingMiddleware) DeleteJob(ctx context.Context, id int) (err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "DeleteJob", "id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.DeleteJob(ctx, id)
+}
+
+func (mw loggingMiddleware) StartJob(ctx context.Context, id int) (j Job, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "StartJob", "id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.StartJob(ctx, id)
+}
+
+func (mw loggingMiddleware) StopJob(ctx context.Context, id int) (j Job, err error) {
+	defer func(begin time.Time) {
+		mw.logger.Log("method", "StopJob", "id", id, "took", time.Since(begin), "err", err)
+	}(time.Now())
+	return mw.next.StopJob(ctx, id)
+}
+
 func BackendMiddleware(backend IBackend) Middleware {
 	return func(next IService) IService {
 		return &backendMiddleware{
@@ -80,33 +255,79 @@ type backendMiddleware struct {
 	backend IBackend
 }
 
-func (mw backendMiddleware) GetMessage(ctx context.Context, id int) (m Message, err error) {
-	d, e := mw.next.GetMessage(ctx, id)
-	// TODO: request from backend when message not existed
-	if e != nil {
-		e = mw.backend.GetMessage(id)
-	}
-	return d, e
+func (mw backendMiddleware) ListTxMessages(ctx context.Context, q MessageQuery) (l MessageList, err error) {
+	return mw.next.ListTxMessages(ctx, q)
+}
+
+func (mw backendMiddleware) GetTxMessage(ctx context.Context, id int) (m Message, err error) {
+	return mw.next.GetTxMessage(ctx, id)
 }
 
-func (mw backendMiddleware) PostMessage(ctx context.Context, m Message) (err error) {
-	e := mw.next.PostMessage(ctx, m)
+func (mw backendMiddleware) PostTxMessage(ctx context.Context, m Message) (err error) {
+	if mw.listenOnly() {
+		return ErrBackendListenOnly
+	}
+	e := mw.next.PostTxMessage(ctx, m)
 	if e == nil {
 		e = mw.backend.PostMessage(m)
+		// Message never made it onto the bus, allow it to be posted again
+		if e != nil {
+			_ = mw.next.DeleteTxMessage(ctx, int(m.ID))
+		}
 	}
 	return e
 }
 
-func (mw backendMiddleware) PutMessage(ctx context.Context, id int, m Message) (err error) {
-	e := mw.next.PutMessage(ctx, id, m)
+func (mw backendMiddleware) PutTxMessage(ctx context.Context, id int, m Message) (err error) {
+	if mw.listenOnly() {
+		return ErrBackendListenOnly
+	}
+	prev, _ := mw.next.GetTxMessage(ctx, id)
+	e := mw.next.PutTxMessage(ctx, id, m)
 	if e == nil {
+		m.ID = uint32(id)
 		e = mw.backend.PostMessage(m)
+		// Message never made it onto the bus, keep the one last transmitted
+		if e != nil {
+			_ = mw.next.PutTxMessage(ctx, id, prev)
+		}
 	}
 	return e
 }
 
-func (mw backendMiddleware) DeleteMessage(ctx context.Context, id int) (err error) {
-	return mw.next.DeleteMessage(ctx, id)
+func (mw backendMiddleware) DeleteTxMessage(ctx context.Context, id int) (err error) {
+	return mw.next.DeleteTxMessage(ctx, id)
+}
+
+func (mw backendMiddleware) ListRxMessages(ctx context.Context, q MessageQuery) (l MessageList, err error) {
+	return mw.next.ListRxMessages(ctx, q)
+}
+
+func (mw backendMiddleware) GetRxMessage(ctx context.Context, id int) (m Message, err error) {
+	return mw.next.GetRxMessage(ctx, id)
+}
+
+func (mw backendMiddleware) DeleteRxMessage(ctx context.Context, id int) (err error) {
+	return mw.next.DeleteRxMessage(ctx, id)
+}
+
+func (mw backendMiddleware) GetHistory(ctx context.Context, id int, since time.Time, limit int) (h []Message, err error) {
+	return mw.next.GetHistory(ctx, id, since, limit)
+}
+
+func (mw backendMiddleware) GetHistoryStats(ctx context.Context) (st HistoryStats, err error) {
+	return mw.next.GetHistoryStats(ctx)
+}
+
+func (mw backendMiddleware) RequestMessage(ctx context.Context, id int, r RemoteRequest) (m Message, err error) {
+	if mw.listenOnly() {
+		return Message{}, ErrBackendListenOnly
+	}
+	d, e := mw.next.RequestMessage(ctx, id, r)
+	if e == nil {
+		d, e = mw.backend.RemoteRequest(id, r.DLC, time.Duration(r.Timeout)*time.Millisecond)
+	}
+	return d, e
 }
 
 func (mw backendMiddleware) Reboot(ctx context.Context) (err error) {
@@ -124,3 +345,167 @@ func (mw backendMiddleware) Unlock(ctx context.Context) (err error) {
 	}
 	return e
 }
+
+func (mw backendMiddleware) GetBitrate(ctx context.Context) (br Bitrate, err error) {
+	b, e := mw.next.GetBitrate(ctx)
+	if e == nil {
+		b, e = mw.backend.GetBitrate()
+	}
+	return b, e
+}
+
+func (mw backendMiddleware) SetBitrate(ctx context.Context, br Bitrate) (err error) {
+	e := mw.next.SetBitrate(ctx, br)
+	if e == nil {
+		e = mw.backend.SetBitrate(br)
+	}
+	return e
+}
+
+func (mw backendMiddleware) GetAcceptance(ctx context.Context) (a Acceptance, err error) {
+	d, e := mw.next.GetAcceptance(ctx)
+	if e == nil {
+		d, e = mw.backend.GetAcceptance()
+	}
+	return d, e
+}
+
+func (mw backendMiddleware) SetAcceptance(ctx context.Context, a Acceptance) (err error) {
+	e := mw.next.SetAcceptance(ctx, a)
+	if e == nil {
+		e = mw.backend.SetAcceptance(a)
+	}
+	return e
+}
+
+func (mw backendMiddleware) GetFilters(ctx context.Context) (f []Filter, err error) {
+	d, e := mw.next.GetFilters(ctx)
+	if e == nil {
+		d, e = mw.backend.GetFilters()
+	}
+	return d, e
+}
+
+func (mw backendMiddleware) SetFilters(ctx context.Context, f []Filter) (err error) {
+	e := mw.next.SetFilters(ctx, f)
+	if e == nil {
+		e = mw.backend.SetFilters(f)
+	}
+	return e
+}
+
+func (mw backendMiddleware) SetMode(ctx context.Context, mode string) (err error) {
+	e := mw.next.SetMode(ctx, mode)
+	if e == nil {
+		e = mw.backend.SetMode(mode)
+	}
+	return e
+}
+
+func (mw backendMiddleware) GetStatus(ctx context.Context) (st Status, err error) {
+	d, e := mw.next.GetStatus(ctx)
+	if e == nil {
+		d, e = mw.backend.GetStatus()
+	}
+	return d, e
+}
+
+func (mw backendMiddleware) GetDevice(ctx context.Context, refresh bool) (d DeviceInfo, err error) {
+	d, e := mw.next.GetDevice(ctx, refresh)
+	if e == nil {
+		if refresh {
+			d, e = mw.backend.QueryDeviceInfo()
+		} else {
+			d, e = mw.backend.GetDeviceInfo()
+		}
+	}
+	return d, e
+}
+
+func (mw backendMiddleware) GetErrors(ctx context.Context) (r ErrorReport, err error) {
+	r, e := mw.next.GetErrors(ctx)
+	if e == nil {
+		r, e = mw.backend.GetErrors()
+	}
+	return r, e
+}
+
+func (mw backendMiddleware) ClearErrors(ctx context.Context) error {
+	e := mw.next.ClearErrors(ctx)
+	if e == nil {
+		e = mw.backend.ClearErrors()
+	}
+	return e
+}
+
+func (mw backendMiddleware) GetJobs(ctx context.Context) (j []Job, err error) {
+	j, e := mw.next.GetJobs(ctx)
+	if e == nil {
+		j, e = mw.backend.GetJobs()
+	}
+	return j, e
+}
+
+func (mw backendMiddleware) PostJob(ctx context.Context, j Job) (r Job, err error) {
+	if mw.listenOnly() {
+		return Job{}, ErrBackendListenOnly
+	}
+	r, e := mw.next.PostJob(ctx, j)
+	if e == nil {
+		r, e = mw.backend.AddJob(j)
+	}
+	return r, e
+}
+
+func (mw backendMiddleware) GetJob(ctx context.Context, id int) (j Job, err error) {
+	j, e := mw.next.GetJob(ctx, id)
+	if e == nil {
+		j, e = mw.backend.GetJob(id)
+	}
+	return j, e
+}
+
+func (mw backendMiddleware) PutJob(ctx context.Context, id int, j Job) (r Job, err error) {
+	if mw.listenOnly() {
+		return Job{}, ErrBackendListenOnly
+	}
+	r, e := mw.next.PutJob(ctx, id, j)
+	if e == nil {
+		r, e = mw.backend.UpdateJob(id, j)
+	}
+	return r, e
+}
+
+func (mw backendMiddleware) DeleteJob(ctx context.Context, id int) error {
+	e := mw.next.DeleteJob(ctx, id)
+	if e == nil {
+		e = mw.backend.DeleteJob(id)
+	}
+	return e
+}
+
+func (mw backendMiddleware) StartJob(ctx context.Context, id int) (j Job, err error) {
+	if mw.listenOnly() {
+		return Job{}, ErrBackendListenOnly
+	}
+	j, e := mw.next.StartJob(ctx, id)
+	if e == nil {
+		j, e = mw.backend.StartJob(id)
+	}
+	return j, e
+}
+
+func (mw backendMiddleware) StopJob(ctx context.Context, id int) (j Job, err error) {
+	j, e := mw.next.StopJob(ctx, id)
+	if e == nil {
+		j, e = mw.backend.StopJob(id)
+	}
+	return j, e
+}
+
+// listenOnly reports whether the backend must not transmit, in which case
+// requests are rejected rather than queued
+func (mw backendMiddleware) listenOnly() bool {
+	st, err := mw.backend.GetStatus()
+	return err == nil && st.Mode == MODE_LISTEN_ONLY
+}
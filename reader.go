@@ -0,0 +1,111 @@
+package slcansvc
+
+// rxQueueSize is the number of parsed lines buffered between the reader
+// and Handler, about 100ms of a fully loaded 1 Mbit/s bus
+const rxQueueSize = 1024
+
+// rxBufferSize is the size of the reads from the link
+const rxBufferSize = 4096
+
+const (
+	rxInvalid = iota
+	rxAck
+	rxNack
+	rxReply
+	rxError
+	rxFrame
+)
+
+// rxLine is a line received from the SLCAN device, parsed by the reader.
+// A link failure is delivered as the last line, with err set.
+type rxLine struct {
+	kind  int
+	reply string
+	msg   Message
+	event ErrorEvent
+	err   error
+}
+
+// reader reads the link into a large buffer, splits the stream into lines
+// and parses them, until the link fails or is closed
+func reader(s Link, rx chan<- rxLine) {
+	defer close(rx)
+	var sc slcanScanner
+	buf := make([]byte, rxBufferSize)
+	for {
+		n, err := s.Read(buf)
+		if err != nil {
+			rx <- rxLine{err: err}
+			return
+		}
+		sc.feed(buf[:n], func(l []byte) {
+			rx <- parseLine(l)
+		})
+	}
+}
+
+// slcanScanner splits the byte stream from the SLCAN device into lines
+// terminated by '\r', '\n' or '\a', keeping partial lines across reads
+type slcanScanner struct {
+	line []byte
+	skip bool
+}
+
+// feed hands every line completed by p, including its terminator, to emit,
+// which must not retain the line. Lines longer than slcanMaxLine are
+// dropped.
+func (sc *slcanScanner) feed(p []byte, emit func([]byte)) {
+	start := 0
+	for i, c := range p {
+		if c != '\r' && c != '\n' && c != '\a' {
+			continue
+		}
+		l := p[start : i+1]
+		if len(sc.line) > 0 {
+			sc.line = append(sc.line, l...)
+			l = sc.line
+		}
+		if !sc.skip && len(l) <= slcanMaxLine {
+			emit(l)
+		}
+		sc.line, sc.skip = sc.line[:0], false
+		start = i + 1
+	}
+	if rest := p[start:]; len(rest) > 0 && !sc.skip {
+		if len(sc.line)+len(rest) > slcanMaxLine {
+			// Never terminated, resynchronise on the next terminator
+			sc.line, sc.skip = sc.line[:0], true
+			return
+		}
+		sc.line = append(sc.line, rest...)
+	}
+}
+
+// parseLine classifies and decodes a line received from the SLCAN device,
+// including its terminator
+func parseLine(l []byte) rxLine {
+	switch {
+	case l[len(l)-1] == '\a':
+		return rxLine{kind: rxNack}
+	case len(l) == 1 && l[0] == '\r':
+		return rxLine{kind: rxAck}
+	case len(l) == 2 && (l[0] == 'z' || l[0] == 'Z'):
+		return rxLine{kind: rxAck}
+	case l[0] == 'V' || l[0] == 'v' || l[0] == 'N' || l[0] == 'F':
+		// Version, serial number and status flags query responses
+		return rxLine{kind: rxReply, reply: string(l[1 : len(l)-1])}
+	case l[0] == 'e' || l[0] == 's':
+		// Error and state frames
+		e, err := decapsSlcanError(l)
+		if err != nil {
+			return rxLine{kind: rxInvalid}
+		}
+		return rxLine{kind: rxError, event: e}
+	default:
+		m, err := decapsSlcanFrame(l)
+		if err != nil {
+			return rxLine{kind: rxInvalid}
+		}
+		return rxLine{kind: rxFrame, msg: m}
+	}
+}
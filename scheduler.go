@@ -0,0 +1,239 @@
+package slcansvc
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSchedulerNotFound = errors.New("Scheduler: job not found")
+)
+
+const (
+	JOB_STOPPED = "stopped"
+	JOB_RUNNING = "running"
+	JOB_PAUSED  = "paused"
+)
+
+// Job transmits Message every Period milliseconds, Count times, or until
+// stopped when Count is zero.
+type Job struct {
+	ID      int     `json:"id" example:"1"`
+	Message Message `json:"message"`
+	Period  int     `json:"period" example:"100"`
+	Count   int     `json:"count,omitempty" example:"0"`
+	// Running jobs are paused while the backend is on hold
+	State string `json:"state" enums:"stopped,running,paused" example:"running"`
+	// Frames transmitted and failed since the job was last started
+	Sent   int `json:"sent" example:"42"`
+	Failed int `json:"failed" example:"0"`
+}
+
+// scheduler transmits the frames of cyclic jobs through post, skipping
+// their slots while the link to the device is not connected
+type scheduler struct {
+	post   func(Message) error
+	status func() (Status, error)
+
+	mtx    sync.Mutex
+	next   int
+	jobs   map[int]*job
+	closed bool
+}
+
+// job is a scheduled Job, stop is closed to stop its goroutine and is nil
+// while stopped
+type job struct {
+	Job
+	stop chan struct{}
+}
+
+// errSchedulerPaused skips a slot while the backend is on hold
+var errSchedulerPaused = errors.New("Scheduler: paused")
+
+func newScheduler(post func(Message) error, status func() (Status, error)) *scheduler {
+	return &scheduler{post: post, status: status, next: 1, jobs: make(map[int]*job)}
+}
+
+func (s *scheduler) list() []Job {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	l := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		l = append(l, j.Job)
+	}
+	sort.Slice(l, func(i, k int) bool { return l[i].ID < l[k].ID })
+	return l
+}
+
+func (s *scheduler) get(id int) (Job, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrSchedulerNotFound
+	}
+	return j.Job, nil
+}
+
+// add schedules a new job and starts it
+func (s *scheduler) add(spec Job) (Job, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return Job{}, ErrBackendClosed
+	}
+	j := &job{Job: Job{ID: s.next, Message: spec.Message, Period: spec.Period, Count: spec.Count}}
+	s.next += 1
+	s.jobs[j.ID] = j
+	s.start(j)
+	return j.Job, nil
+}
+
+// update replaces the frame, period and count of a job, restarting it if
+// running
+func (s *scheduler) update(id int, spec Job) (Job, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrSchedulerNotFound
+	}
+	if s.closed {
+		return Job{}, ErrBackendClosed
+	}
+	running := j.stop != nil
+	s.halt(j)
+	j.Message, j.Period, j.Count = spec.Message, spec.Period, spec.Count
+	if running {
+		s.start(j)
+	}
+	return j.Job, nil
+}
+
+func (s *scheduler) remove(id int) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrSchedulerNotFound
+	}
+	s.halt(j)
+	delete(s.jobs, id)
+	return nil
+}
+
+// resume starts a stopped job over, a running job is left alone
+func (s *scheduler) resume(id int) (Job, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrSchedulerNotFound
+	}
+	if s.closed {
+		return Job{}, ErrBackendClosed
+	}
+	if j.stop == nil {
+		s.start(j)
+	}
+	return j.Job, nil
+}
+
+func (s *scheduler) suspend(id int) (Job, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return Job{}, ErrSchedulerNotFound
+	}
+	s.halt(j)
+	return j.Job, nil
+}
+
+// close stops every job when the backend shuts down, jobs are not started
+// any more from then on
+func (s *scheduler) close() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.closed = true
+	for _, j := range s.jobs {
+		s.halt(j)
+	}
+}
+
+// start runs a job from its first slot, s.mtx must be held
+func (s *scheduler) start(j *job) {
+	j.stop = make(chan struct{})
+	j.State, j.Sent, j.Failed = JOB_RUNNING, 0, 0
+	go s.run(j, j.Job, j.stop)
+}
+
+// halt stops a job, s.mtx must be held
+func (s *scheduler) halt(j *job) {
+	if j.stop != nil {
+		close(j.stop)
+		j.stop = nil
+	}
+	j.State = JOB_STOPPED
+}
+
+// run transmits the frames of a job. Slots are anchored to the start of
+// the job rather than to the previous transmission, so delays never
+// accumulate, and slots missed altogether are skipped.
+func (s *scheduler) run(j *job, spec Job, stop chan struct{}) {
+	period := time.Duration(spec.Period) * time.Millisecond
+	start := time.Now()
+	t := time.NewTimer(0)
+	defer t.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+		next := start.Add((time.Since(start)/period + 1) * period)
+		err := s.transmit(spec.Message, next)
+
+		s.mtx.Lock()
+		if j.stop != stop {
+			// Stopped or restarted meanwhile
+			s.mtx.Unlock()
+			return
+		}
+		switch err {
+		case nil:
+			j.State = JOB_RUNNING
+			j.Sent += 1
+		case errSchedulerPaused:
+			j.State = JOB_PAUSED
+		default:
+			j.State = JOB_RUNNING
+			j.Failed += 1
+		}
+		if spec.Count > 0 && j.Sent >= spec.Count {
+			s.halt(j)
+			s.mtx.Unlock()
+			return
+		}
+		s.mtx.Unlock()
+		t.Reset(time.Until(next))
+	}
+}
+
+// transmit posts a frame, retrying until deadline while other frontend
+// requests keep the backend busy
+func (s *scheduler) transmit(m Message, deadline time.Time) error {
+	for {
+		if st, _ := s.status(); st.Link != LINK_CONNECTED {
+			return errSchedulerPaused
+		}
+		err := s.post(m)
+		if err != ErrBackendOnhold || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,133 @@
+package slcansvc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTx records the frames posted by the scheduler, taking delay to post
+// each
+type fakeTx struct {
+	mtx   sync.Mutex
+	link  string
+	delay time.Duration
+	at    []time.Time
+}
+
+func (f *fakeTx) post(m Message) error {
+	time.Sleep(f.delay)
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.at = append(f.at, time.Now())
+	return nil
+}
+
+func (f *fakeTx) status() (Status, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return Status{Link: f.link}, nil
+}
+
+func (f *fakeTx) setLink(state string) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.link = state
+}
+
+func (f *fakeTx) sent() []time.Time {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return append([]time.Time{}, f.at...)
+}
+
+func TestSchedulerTiming(t *testing.T) {
+	tx := &fakeTx{link: LINK_CONNECTED, delay: 3 * time.Millisecond}
+	s := newScheduler(tx.post, tx.status)
+	begin := time.Now()
+	j, _ := s.add(Job{Message: Message{ID: 0x100}, Period: 10, Count: 10})
+	assert.Equal(t, Job{ID: 1, Message: Message{ID: 0x100}, Period: 10, Count: 10, State: JOB_RUNNING}, j)
+
+	// job stops after count frames
+	assert.Eventually(t, func() bool {
+		j, _ := s.get(1)
+		return j.State == JOB_STOPPED
+	}, time.Second, time.Millisecond)
+	j, _ = s.get(1)
+	assert.Equal(t, 10, j.Sent)
+
+	// first frame is transmitted right away, and slow transmissions do not
+	// delay the following slots
+	at := tx.sent()
+	assert.Equal(t, 10, len(at))
+	last := at[len(at)-1].Sub(begin)
+	assert.True(t, last >= 90*time.Millisecond && last < 110*time.Millisecond, last)
+}
+
+func TestSchedulerPause(t *testing.T) {
+	tx := &fakeTx{link: LINK_CONNECTED}
+	s := newScheduler(tx.post, tx.status)
+	s.add(Job{Message: Message{ID: 0x100}, Period: 1})
+	assert.Eventually(t, func() bool {
+		j, _ := s.get(1)
+		return j.Sent > 0
+	}, time.Second, time.Millisecond)
+
+	// slots are skipped while the backend is on hold
+	tx.setLink(LINK_REBOOTING)
+	assert.Eventually(t, func() bool {
+		j, _ := s.get(1)
+		return j.State == JOB_PAUSED
+	}, time.Second, time.Millisecond)
+	n := len(tx.sent())
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, n, len(tx.sent()))
+
+	tx.setLink(LINK_CONNECTED)
+	assert.Eventually(t, func() bool {
+		j, _ := s.get(1)
+		return j.State == JOB_RUNNING && len(tx.sent()) > n
+	}, time.Second, time.Millisecond)
+	s.close()
+}
+
+func TestSchedulerJobs(t *testing.T) {
+	tx := &fakeTx{link: LINK_CONNECTED}
+	s := newScheduler(tx.post, tx.status)
+	s.add(Job{Message: Message{ID: 0x100}, Period: 1000})
+	s.add(Job{Message: Message{ID: 0x200}, Period: 1000})
+
+	j, err := s.suspend(1)
+	assert.Equal(t, JOB_STOPPED, j.State)
+	assert.Equal(t, nil, err)
+	j, err = s.update(1, Job{Message: Message{ID: 0x101}, Period: 500, Count: 3})
+	assert.Equal(t, Job{ID: 1, Message: Message{ID: 0x101}, Period: 500, Count: 3, State: JOB_STOPPED}, j)
+	assert.Equal(t, nil, err)
+	j, err = s.resume(1)
+	assert.Equal(t, JOB_RUNNING, j.State)
+	assert.Equal(t, nil, err)
+
+	assert.Equal(t, nil, s.remove(2))
+	l := s.list()
+	assert.Equal(t, 1, len(l))
+	assert.Equal(t, 1, l[0].ID)
+
+	// unknown jobs
+	_, err = s.get(2)
+	assert.Equal(t, ErrSchedulerNotFound, err)
+	_, err = s.update(2, Job{Period: 1})
+	assert.Equal(t, ErrSchedulerNotFound, err)
+	assert.Equal(t, ErrSchedulerNotFound, s.remove(2))
+	_, err = s.resume(2)
+	assert.Equal(t, ErrSchedulerNotFound, err)
+
+	// job IDs are never reused
+	j, _ = s.add(Job{Message: Message{ID: 0x300}, Period: 1000})
+	assert.Equal(t, 3, j.ID)
+	s.close()
+	for _, j := range s.list() {
+		assert.Equal(t, JOB_STOPPED, j.State)
+	}
+}
@@ -3,10 +3,19 @@ package slcansvc
 import (
 	"context"
 	"errors"
+	"time"
 )
 
 var (
-	ErrServiceInvalidID = errors.New("Service: invalid id")
+	ErrServiceInvalidID      = errors.New("Service: invalid id")
+	ErrServiceInvalidBitrate = errors.New("Service: invalid bitrate")
+	ErrServiceInvalidDLC     = errors.New("Service: invalid dlc")
+	ErrServiceInvalidFilter  = errors.New("Service: invalid filter")
+	ErrServiceInvalidMode    = errors.New("Service: invalid mode")
+	ErrServiceInvalidPeriod  = errors.New("Service: invalid period")
+	ErrServiceInvalidCount   = errors.New("Service: invalid count")
+	ErrServiceInvalidLimit   = errors.New("Service: invalid limit")
+	ErrServiceInvalidQuery   = errors.New("Service: invalid query")
 )
 
 const (
@@ -15,102 +24,357 @@ const (
 )
 
 type IService interface {
-	GetMessage(ctx context.Context, id int) (Message, error)
-	PostMessage(ctx context.Context, m Message) error
-	PutMessage(ctx context.Context, id int, m Message) error
-	DeleteMessage(ctx context.Context, id int) error
+	ListTxMessages(ctx context.Context, q MessageQuery) (MessageList, error)
+	GetTxMessage(ctx context.Context, id int) (Message, error)
+	PostTxMessage(ctx context.Context, m Message) error
+	PutTxMessage(ctx context.Context, id int, m Message) error
+	DeleteTxMessage(ctx context.Context, id int) error
+	ListRxMessages(ctx context.Context, q MessageQuery) (MessageList, error)
+	GetRxMessage(ctx context.Context, id int) (Message, error)
+	DeleteRxMessage(ctx context.Context, id int) error
+	GetHistory(ctx context.Context, id int, since time.Time, limit int) ([]Message, error)
+	GetHistoryStats(ctx context.Context) (HistoryStats, error)
+	RequestMessage(ctx context.Context, id int, r RemoteRequest) (Message, error)
 	Reboot(ctx context.Context) error
 	Unlock(ctx context.Context) error
+	GetBitrate(ctx context.Context) (Bitrate, error)
+	SetBitrate(ctx context.Context, br Bitrate) error
+	GetAcceptance(ctx context.Context) (Acceptance, error)
+	SetAcceptance(ctx context.Context, a Acceptance) error
+	GetFilters(ctx context.Context) ([]Filter, error)
+	SetFilters(ctx context.Context, f []Filter) error
+	SetMode(ctx context.Context, mode string) error
+	GetStatus(ctx context.Context) (Status, error)
+	GetDevice(ctx context.Context, refresh bool) (DeviceInfo, error)
+	GetErrors(ctx context.Context) (ErrorReport, error)
+	ClearErrors(ctx context.Context) error
+	GetJobs(ctx context.Context) ([]Job, error)
+	PostJob(ctx context.Context, j Job) (Job, error)
+	GetJob(ctx context.Context, id int) (Job, error)
+	PutJob(ctx context.Context, id int, j Job) (Job, error)
+	DeleteJob(ctx context.Context, id int) error
+	StartJob(ctx context.Context, id int) (Job, error)
+	StopJob(ctx context.Context, id int) (Job, error)
 }
 
-type Service struct{}
+// RemoteRequest describes a remote transmission request, Timeout is how
+// long to wait for the responding data frame in milliseconds, zero for
+// not waiting at all
+type RemoteRequest struct {
+	DLC     int `json:"dlc" example:"8"`
+	Timeout int `json:"timeout" example:"500"`
+}
+
+type Service struct {
+	db Database
+}
 
-func NewService() IService {
-	return &Service{}
+// NewService returns a service storing messages in d, the database its
+// channel's backend writes received frames to.
+func NewService(d Database) IService {
+	return &Service{db: d}
 }
 
-// GetMessage godoc
+// ListTxMessages godoc
 //
-//	@Summary	Retrieve CAN message
+//	@Summary	List CAN messages configured for transmission
 //	@Schemes
-//	@Description	Retrieve CAN message by specifying CAN ID
+//	@Description	List CAN messages of the TX table, optionally filtered by CAN ID range, frame type and data, either as a substring or as hex bytes equal to match in the bits set in mask
 //	@Tags			SLCAN
-//	@Param			int	path	int	true	"CAN ID"	minimum(0)	maximum(536870911)
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			from	query	int		false	"Lowest CAN ID"	minimum(0)	maximum(536870911)
+//	@Param			to		query	int		false	"Highest CAN ID, unbounded by default"	minimum(0)	maximum(536870911)
+//	@Param			type	query	string	false	"Frame type"	Enums(std, ext)
+//	@Param			data	query	string	false	"Data substring, hex bytes"
+//	@Param			mask	query	string	false	"Data mask, hex bytes"
+//	@Param			match	query	string	false	"Data match, hex bytes as long as mask"
+//	@Param			sort	query	string	false	"Sort order, descending when prefixed with -"	Enums(id, -id, received_at, -received_at)
+//	@Param			offset	query	int		false	"Number of messages skipped"	minimum(0)
+//	@Param			limit	query	int		false	"Number of messages listed, 100 by default"	minimum(1)	maximum(1000)
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
 //	@Accept			json
 //	@Produce		json
-//	@Success		200	{array}	slcansvc.Message
+//	@Success		200	{object}	slcansvc.MessageList
 //	@Failure		400
 //	@Failure		404
 //	@Failure		500
-//	@Router			/slcan/{id} [get]
-func (s *Service) GetMessage(ctx context.Context, id int) (Message, error) {
+//	@Router			/slcan/{channel}/tx [get]
+func (s *Service) ListTxMessages(ctx context.Context, q MessageQuery) (MessageList, error) {
+	q, err := validateQuery(q)
+	if err != nil {
+		return MessageList{}, err
+	}
+	return s.db.QueryTx(q), nil
+}
+
+// GetTxMessage godoc
+//
+//	@Summary	Retrieve CAN message configured for transmission
+//	@Schemes
+//	@Description	Retrieve CAN message of the TX table by specifying CAN ID
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id	path	int	true	"CAN ID"	minimum(0)	maximum(536870911)
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Message
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/tx/{id} [get]
+func (s *Service) GetTxMessage(ctx context.Context, id int) (Message, error) {
 	if id < CAN_ID_MIN || id > CAN_ID_MAX {
 		return Message{}, ErrServiceInvalidID
 	}
-	return db.GetData(uint32(id))
+	return s.db.GetTx(uint32(id))
 }
 
-// PostMessage godoc
+// PostTxMessage godoc
 //
-//	@Summary	Add new CAN message
+//	@Summary	Add and transmit new CAN message
 //	@Schemes
-//	@Description	Add new CAN message by specifying CAN ID and data
+//	@Description	Add new CAN message to the TX table and transmit it, by specifying CAN ID and data, up to 8 bytes for classic CAN or one of 0-8, 12, 16, 20, 24, 32, 48, 64 bytes for CAN FD. Frames received with the same CAN ID do not conflict.
 //	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
 //	@Param			array	body	slcansvc.Message	false	"CAN Message"
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
 //	@Accept			json
 //	@Produce		json
 //	@Success		200
 //	@Failure		400
 //	@Failure		404
 //	@Failure		500
-//	@Router			/slcan [post]
-func (s *Service) PostMessage(ctx context.Context, m Message) error {
+//	@Router			/slcan/{channel}/tx [post]
+func (s *Service) PostTxMessage(ctx context.Context, m Message) error {
 	if m.ID > CAN_ID_MAX {
 		return ErrServiceInvalidID
 	}
-	return db.PostData(m)
+	return s.db.PostTx(m)
 }
 
-// PutMessage godoc
+// PutTxMessage godoc
 //
-//	@Summary	Update existing CAN message
+//	@Summary	Update and transmit existing CAN message
 //	@Schemes
-//	@Description	Update existing CAN message by specifying CAN ID and data
+//	@Description	Update CAN message of the TX table and transmit it, by specifying CAN ID and data
 //	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id		path	int					true	"CAN ID"	minimum(0)	maximum(536870911)
 //	@Param			array	body	slcansvc.Message	false	"CAN Message"
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/tx/{id} [put]
+func (s *Service) PutTxMessage(ctx context.Context, id int, m Message) error {
+	if id < CAN_ID_MIN || id > CAN_ID_MAX || (m.ID != 0 && m.ID != uint32(id)) {
+		return ErrServiceInvalidID
+	}
+	m.ID = uint32(id)
+	return s.db.PutTx(uint32(id), m)
+}
+
+// DeleteTxMessage godoc
+//
+//	@Summary	Remove CAN message configured for transmission
+//	@Schemes
+//	@Description	Remove CAN message from the TX table by specifying CAN ID, nothing is transmitted
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id	path	int	true	"CAN ID"	minimum(0)	maximum(536870911)
 //	@Accept			json
 //	@Produce		json
 //	@Success		200
 //	@Failure		400
 //	@Failure		404
 //	@Failure		500
-//	@Router			/slcan/{id} [put]
-func (s *Service) PutMessage(ctx context.Context, id int, m Message) error {
+//	@Router			/slcan/{channel}/tx/{id} [delete]
+func (s *Service) DeleteTxMessage(ctx context.Context, id int) error {
 	if id < CAN_ID_MIN || id > CAN_ID_MAX {
 		return ErrServiceInvalidID
 	}
-	return db.PutData(uint32(id), m)
+	return s.db.DeleteTx(uint32(id))
+}
+
+// ListRxMessages godoc
+//
+//	@Summary	List CAN messages received
+//	@Schemes
+//	@Description	List latest frame received for every CAN ID seen on the bus, optionally filtered by CAN ID range, frame type, reception time and data, either as a substring or as hex bytes equal to match in the bits set in mask
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			from	query	int		false	"Lowest CAN ID"	minimum(0)	maximum(536870911)
+//	@Param			to		query	int		false	"Highest CAN ID, unbounded by default"	minimum(0)	maximum(536870911)
+//	@Param			type	query	string	false	"Frame type"	Enums(std, ext)
+//	@Param			since	query	string	false	"Received after, RFC 3339"	format(date-time)
+//	@Param			data	query	string	false	"Data substring, hex bytes"
+//	@Param			mask	query	string	false	"Data mask, hex bytes"
+//	@Param			match	query	string	false	"Data match, hex bytes as long as mask"
+//	@Param			sort	query	string	false	"Sort order, descending when prefixed with -"	Enums(id, -id, received_at, -received_at)
+//	@Param			offset	query	int		false	"Number of messages skipped"	minimum(0)
+//	@Param			limit	query	int		false	"Number of messages listed, 100 by default"	minimum(1)	maximum(1000)
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.MessageList
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/rx [get]
+func (s *Service) ListRxMessages(ctx context.Context, q MessageQuery) (MessageList, error) {
+	q, err := validateQuery(q)
+	if err != nil {
+		return MessageList{}, err
+	}
+	return s.db.QueryRx(q), nil
 }
 
-// DeleteMessage godoc
+// GetRxMessage godoc
 //
-//	@Summary	Remove CAN message
+//	@Summary	Retrieve CAN message received
 //	@Schemes
-//	@Description	Remove CAN message by specifying CAN ID
+//	@Description	Retrieve latest frame received by specifying CAN ID
 //	@Tags			SLCAN
-//	@Param			int	path	int	true	"CAN ID"	minimum(0)	maximum(536870911)
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id	path	int	true	"CAN ID"	minimum(0)	maximum(536870911)
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Message
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/rx/{id} [get]
+func (s *Service) GetRxMessage(ctx context.Context, id int) (Message, error) {
+	if id < CAN_ID_MIN || id > CAN_ID_MAX {
+		return Message{}, ErrServiceInvalidID
+	}
+	return s.db.GetRx(uint32(id))
+}
+
+// DeleteRxMessage godoc
+//
+//	@Summary	Forget CAN message received
+//	@Schemes
+//	@Description	Remove latest frame received and its history by specifying CAN ID, until received again
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id	path	int	true	"CAN ID"	minimum(0)	maximum(536870911)
 //	@Accept			json
 //	@Produce		json
 //	@Success		200
 //	@Failure		400
 //	@Failure		404
 //	@Failure		500
-//	@Router			/slcan/{id} [delete]
-func (s *Service) DeleteMessage(ctx context.Context, id int) error {
+//	@Router			/slcan/{channel}/rx/{id} [delete]
+func (s *Service) DeleteRxMessage(ctx context.Context, id int) error {
 	if id < CAN_ID_MIN || id > CAN_ID_MAX {
 		return ErrServiceInvalidID
 	}
-	return db.DeleteData(uint32(id))
+	return s.db.DeleteRx(uint32(id))
+}
+
+// validateQuery checks a query listing messages, defaulting its limit
+func validateQuery(q MessageQuery) (MessageQuery, error) {
+	if q.From > CAN_ID_MAX || q.To > CAN_ID_MAX || (q.To != 0 && q.To < q.From) {
+		return q, ErrServiceInvalidID
+	}
+	if q.Type != "" && q.Type != FILTER_STANDARD && q.Type != FILTER_EXTENDED {
+		return q, ErrServiceInvalidQuery
+	}
+	switch q.Sort {
+	case "", SORT_ID, "-" + SORT_ID, SORT_RECEIVED_AT, "-" + SORT_RECEIVED_AT:
+	default:
+		return q, ErrServiceInvalidQuery
+	}
+	if len(q.Mask) != len(q.Match) || q.Offset < 0 {
+		return q, ErrServiceInvalidQuery
+	}
+	if q.Limit == 0 {
+		q.Limit = DefaultListLimit
+	}
+	if q.Limit < 0 || q.Limit > MaxListLimit {
+		return q, ErrServiceInvalidLimit
+	}
+	return q, nil
+}
+
+// GetHistory godoc
+//
+//	@Summary	Retrieve CAN message history
+//	@Schemes
+//	@Description	Retrieve frames received for CAN ID, oldest first. Given since, the first limit frames received after since are returned, otherwise the last limit frames.
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id		path	int		true	"CAN ID"	minimum(0)	maximum(536870911)
+//	@Param			since	query	string	false	"Reception time, RFC 3339"	format(date-time)
+//	@Param			limit	query	int		false	"Maximum number of frames, all by default"	minimum(0)
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}	slcansvc.Message
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/rx/{id}/history [get]
+func (s *Service) GetHistory(ctx context.Context, id int, since time.Time, limit int) ([]Message, error) {
+	if id < CAN_ID_MIN || id > CAN_ID_MAX {
+		return nil, ErrServiceInvalidID
+	}
+	if limit < 0 {
+		return nil, ErrServiceInvalidLimit
+	}
+	return s.db.GetHistory(uint32(id), since, limit)
+}
+
+// GetHistoryStats godoc
+//
+//	@Summary	Retrieve CAN message history statistics
+//	@Schemes
+//	@Description	Retrieve number of frames kept in history and estimated memory used, along with frames evicted as the history of their CAN ID was full, as too old, or to stay within the memory limit
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.HistoryStats
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/history [get]
+func (s *Service) GetHistoryStats(ctx context.Context) (HistoryStats, error) {
+	return s.db.GetHistoryStats(), nil
+}
+
+// RequestMessage godoc
+//
+//	@Summary	Request CAN message
+//	@Schemes
+//	@Description	Transmit remote frame by specifying CAN ID and requested DLC, optionally waiting for the responding data frame
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			int		path	int						true	"CAN ID"	minimum(0)	maximum(536870911)
+//	@Param			request	body	slcansvc.RemoteRequest	false	"Remote Request"
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Message
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Failure		504
+//	@Router			/slcan/{channel}/{id}/rtr [post]
+func (s *Service) RequestMessage(ctx context.Context, id int, r RemoteRequest) (Message, error) {
+	if id < CAN_ID_MIN || id > CAN_ID_MAX {
+		return Message{}, ErrServiceInvalidID
+	}
+	if r.DLC < 0 || r.DLC > 8 || r.Timeout < 0 {
+		return Message{}, ErrServiceInvalidDLC
+	}
+	return Message{}, nil
 }
 
 // Reboot godoc
@@ -119,13 +383,14 @@ func (s *Service) DeleteMessage(ctx context.Context, id int) error {
 //	@Schemes
 //	@Description	Reboot SLCAN device for firmware update
 //	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
 //	@Accept			json
 //	@Produce		json
 //	@Success		200
 //	@Failure		400
 //	@Failure		404
 //	@Failure		500
-//	@Router			/slcan/reboot [post]
+//	@Router			/slcan/{channel}/reboot [post]
 func (s *Service) Reboot(ctx context.Context) error {
 	return nil
 }
@@ -136,13 +401,385 @@ func (s *Service) Reboot(ctx context.Context) error {
 //	@Schemes
 //	@Description	Unlock serial backend from the success of firmware update
 //	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
 //	@Accept			json
 //	@Produce		json
 //	@Success		200
 //	@Failure		400
 //	@Failure		404
 //	@Failure		500
-//	@Router			/slcan/unlock [post]
+//	@Router			/slcan/{channel}/unlock [post]
 func (s *Service) Unlock(ctx context.Context) error {
 	return nil
 }
+
+// GetBitrate godoc
+//
+//	@Summary	Retrieve CAN bitrate
+//	@Schemes
+//	@Description	Retrieve CAN bus bitrate currently applied to SLCAN device
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Bitrate
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/config/bitrate [get]
+func (s *Service) GetBitrate(ctx context.Context) (Bitrate, error) {
+	return Bitrate{}, nil
+}
+
+// SetBitrate godoc
+//
+//	@Summary	Configure CAN bitrate
+//	@Schemes
+//	@Description	Configure CAN bus bitrate by specifying either a standard rate (10000 - 1000000) or BTR register values, and optionally the CAN FD data phase rate (1000000 - 8000000)
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			bitrate	body	slcansvc.Bitrate	true	"CAN Bitrate"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/config/bitrate [post]
+func (s *Service) SetBitrate(ctx context.Context, br Bitrate) error {
+	if _, err := bitrateSeq(br); err != nil {
+		return ErrServiceInvalidBitrate
+	}
+	return nil
+}
+
+// GetAcceptance godoc
+//
+//	@Summary	Retrieve acceptance filter
+//	@Schemes
+//	@Description	Retrieve acceptance code and mask currently programmed into SLCAN device
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Acceptance
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/config/acceptance [get]
+func (s *Service) GetAcceptance(ctx context.Context) (Acceptance, error) {
+	return Acceptance{}, nil
+}
+
+// SetAcceptance godoc
+//
+//	@Summary	Configure acceptance filter
+//	@Schemes
+//	@Description	Program acceptance code and mask, 8 hex digits each, into SLCAN device
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			acceptance	body	slcansvc.Acceptance	true	"Acceptance code and mask"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/config/acceptance [post]
+func (s *Service) SetAcceptance(ctx context.Context, a Acceptance) error {
+	if _, err := acceptanceSeq(a); err != nil {
+		return ErrServiceInvalidFilter
+	}
+	return nil
+}
+
+// GetFilters godoc
+//
+//	@Summary	Retrieve software filters
+//	@Schemes
+//	@Description	Retrieve software filters applied to received CAN messages
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}	slcansvc.Filter
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/config/filters [get]
+func (s *Service) GetFilters(ctx context.Context) ([]Filter, error) {
+	return []Filter{}, nil
+}
+
+// SetFilters godoc
+//
+//	@Summary	Replace software filters
+//	@Schemes
+//	@Description	Replace software filters applied to received CAN messages, an empty list accepts every message
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			filters	body	[]slcansvc.Filter	true	"Software filters"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/config/filters [post]
+func (s *Service) SetFilters(ctx context.Context, f []Filter) error {
+	for _, i := range f {
+		if err := i.validate(); err != nil {
+			return ErrServiceInvalidFilter
+		}
+	}
+	return nil
+}
+
+// SetMode godoc
+//
+//	@Summary	Configure SLCAN mode
+//	@Schemes
+//	@Description	Reopen SLCAN channel in either normal or listen-only mode, where the device never acknowledges or transmits frames
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			mode	body	slcansvc.setModeRequest	true	"SLCAN mode"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/config/mode [post]
+func (s *Service) SetMode(ctx context.Context, mode string) error {
+	if mode != MODE_NORMAL && mode != MODE_LISTEN_ONLY {
+		return ErrServiceInvalidMode
+	}
+	return nil
+}
+
+// GetStatus godoc
+//
+//	@Summary	Retrieve SLCAN status
+//	@Schemes
+//	@Description	Retrieve SLCAN channel mode and CAN controller status flags polled from SLCAN device
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Status
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/status [get]
+func (s *Service) GetStatus(ctx context.Context) (Status, error) {
+	return Status{}, nil
+}
+
+// GetDevice godoc
+//
+//	@Summary	Retrieve SLCAN device information
+//	@Schemes
+//	@Description	Retrieve hardware and software versions and serial number of SLCAN device, cached whenever the SLCAN channel is opened unless refresh is requested
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			refresh	query	bool	false	"Query SLCAN device again"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.DeviceInfo
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/device [get]
+func (s *Service) GetDevice(ctx context.Context, refresh bool) (DeviceInfo, error) {
+	return DeviceInfo{}, nil
+}
+
+// GetErrors godoc
+//
+//	@Summary	Retrieve CAN bus errors
+//	@Schemes
+//	@Description	Retrieve most recent CAN bus error events reported by SLCAN device, along with counters per error class and controller state
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.ErrorReport
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/errors [get]
+func (s *Service) GetErrors(ctx context.Context) (ErrorReport, error) {
+	return ErrorReport{}, nil
+}
+
+// ClearErrors godoc
+//
+//	@Summary	Clear CAN bus errors
+//	@Schemes
+//	@Description	Clear CAN bus error events and counters
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/errors [delete]
+func (s *Service) ClearErrors(ctx context.Context) error {
+	return nil
+}
+
+// GetJobs godoc
+//
+//	@Summary	Retrieve cyclic transmission jobs
+//	@Schemes
+//	@Description	Retrieve cyclic transmission jobs along with their state and number of frames transmitted
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{array}	slcansvc.Job
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/jobs [get]
+func (s *Service) GetJobs(ctx context.Context) ([]Job, error) {
+	return nil, nil
+}
+
+// PostJob godoc
+//
+//	@Summary	Add cyclic transmission job
+//	@Schemes
+//	@Description	Add cyclic transmission job, started right away, transmitting CAN message every period milliseconds, count times or until stopped when count is zero. Jobs are paused while SLCAN device is on hold for reboot, and resumed once unlocked.
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			job	body	slcansvc.Job	true	"Cyclic transmission job, id and state are ignored"
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Job
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/jobs [post]
+func (s *Service) PostJob(ctx context.Context, j Job) (Job, error) {
+	return Job{}, validateJob(j)
+}
+
+// GetJob godoc
+//
+//	@Summary	Retrieve cyclic transmission job
+//	@Schemes
+//	@Description	Retrieve cyclic transmission job by specifying job ID
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id	path	int	true	"Job ID"
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Job
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/jobs/{id} [get]
+func (s *Service) GetJob(ctx context.Context, id int) (Job, error) {
+	return Job{}, nil
+}
+
+// PutJob godoc
+//
+//	@Summary	Update cyclic transmission job
+//	@Schemes
+//	@Description	Update CAN message, period and count of cyclic transmission job, restarting it if running
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id	path	int				true	"Job ID"
+//	@Param			job	body	slcansvc.Job	true	"Cyclic transmission job, id and state are ignored"
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Job
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/jobs/{id} [put]
+func (s *Service) PutJob(ctx context.Context, id int, j Job) (Job, error) {
+	return Job{}, validateJob(j)
+}
+
+// DeleteJob godoc
+//
+//	@Summary	Remove cyclic transmission job
+//	@Schemes
+//	@Description	Stop and remove cyclic transmission job by specifying job ID
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id	path	int	true	"Job ID"
+//	@Accept			json
+//	@Produce		json
+//	@Success		200
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/jobs/{id} [delete]
+func (s *Service) DeleteJob(ctx context.Context, id int) error {
+	return nil
+}
+
+// StartJob godoc
+//
+//	@Summary	Start cyclic transmission job
+//	@Schemes
+//	@Description	Start stopped cyclic transmission job over, counting frames transmitted from zero
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id	path	int	true	"Job ID"
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Job
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/jobs/{id}/start [post]
+func (s *Service) StartJob(ctx context.Context, id int) (Job, error) {
+	return Job{}, nil
+}
+
+// StopJob godoc
+//
+//	@Summary	Stop cyclic transmission job
+//	@Schemes
+//	@Description	Stop cyclic transmission job, keeping it for being started again
+//	@Tags			SLCAN
+//	@Param			channel	path	string	true	"Channel name"
+//	@Param			id	path	int	true	"Job ID"
+//	@Param			encoding	query	string	false	"Message data encoding, hex by default"	Enums(hex, base64, array)
+//	@Accept			json
+//	@Produce		json
+//	@Success		200	{object}	slcansvc.Job
+//	@Failure		400
+//	@Failure		404
+//	@Failure		500
+//	@Router			/slcan/{channel}/jobs/{id}/stop [post]
+func (s *Service) StopJob(ctx context.Context, id int) (Job, error) {
+	return Job{}, nil
+}
+
+// validateJob checks the CAN ID, period and count of a job, its frame is
+// validated by the backend
+func validateJob(j Job) error {
+	if j.Message.ID > CAN_ID_MAX {
+		return ErrServiceInvalidID
+	}
+	if j.Period <= 0 {
+		return ErrServiceInvalidPeriod
+	}
+	if j.Count < 0 {
+		return ErrServiceInvalidCount
+	}
+	return nil
+}
@@ -2,36 +2,40 @@ package slcansvc
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestHTTP(t *testing.T) {
-	svc := NewService()
+	db := NewDatabase()
+	svc := NewService(db)
 	mux := MakeHTTPHandler(svc, log.NewNopLogger())
 	srv := httptest.NewServer(mux)
 	defer srv.Close()
 
 	msg := Message{
 		ID:   123,
-		Data: "200rpm",
+		Data: []byte("200rpm"),
 	}
 	jsonMsg, _ := json.Marshal(msg)
-	req, _ := http.NewRequest("POST", srv.URL+"/slcan", bytes.NewBuffer(jsonMsg))
+	req, _ := http.NewRequest("POST", srv.URL+"/slcan/tx", bytes.NewBuffer(jsonMsg))
 	resp, _ := http.DefaultClient.Do(req)
 	body, _ := ioutil.ReadAll(resp.Body)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.Equal(t, "{}", strings.TrimSpace(string(body)))
 
-	var want getMessageResponse
-	req, _ = http.NewRequest("GET", srv.URL+"/slcan/123", nil)
+	var want getTxMessageResponse
+	msg.Direction = DIRECTION_TX
+	req, _ = http.NewRequest("GET", srv.URL+"/slcan/tx/123", nil)
 	resp, _ = http.DefaultClient.Do(req)
 	body, _ = ioutil.ReadAll(resp.Body)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -39,15 +43,15 @@ func TestHTTP(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, msg, want.Msg)
 
-	msg.Data = "201rpm"
+	msg.Data = []byte("201rpm")
 	jsonMsg, _ = json.Marshal(msg)
-	req, _ = http.NewRequest("PUT", srv.URL+"/slcan/123", bytes.NewBuffer(jsonMsg))
+	req, _ = http.NewRequest("PUT", srv.URL+"/slcan/tx/123", bytes.NewBuffer(jsonMsg))
 	resp, _ = http.DefaultClient.Do(req)
 	body, _ = ioutil.ReadAll(resp.Body)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.Equal(t, "{}", strings.TrimSpace(string(body)))
 
-	req, _ = http.NewRequest("GET", srv.URL+"/slcan/123", nil)
+	req, _ = http.NewRequest("GET", srv.URL+"/slcan/tx/123", nil)
 	resp, _ = http.DefaultClient.Do(req)
 	body, _ = ioutil.ReadAll(resp.Body)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
@@ -56,17 +60,347 @@ func TestHTTP(t *testing.T) {
 	assert.Equal(t, msg, want.Msg)
 
 	jsonMsg, _ = json.Marshal(msg)
-	req, _ = http.NewRequest("DELETE", srv.URL+"/slcan/123", bytes.NewBuffer(jsonMsg))
+	req, _ = http.NewRequest("DELETE", srv.URL+"/slcan/tx/123", bytes.NewBuffer(jsonMsg))
 	resp, _ = http.DefaultClient.Do(req)
 	body, _ = ioutil.ReadAll(resp.Body)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
 	assert.Equal(t, "{}", strings.TrimSpace(string(body)))
 
-	// req, _ = http.NewRequest("GET", srv.URL+"/slcan/123", nil)
-	// resp, _ = http.DefaultClient.Do(req)
-	// body, _ = ioutil.ReadAll(resp.Body)
-	// assert.Equal(t, http.StatusOK, resp.StatusCode)
-	// err = json.Unmarshal(body, &want)
-	// assert.NoError(t, err)
-	// assert.Equal(t, Message{}, want.Msg)
+	req, _ = http.NewRequest("GET", srv.URL+"/slcan/tx/123", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	do := func(method, path, body string) *http.Response {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// malformed requests are turned away, whichever layer rejects them
+	resp = do("POST", "/slcan/config/bitrate", `{"rate": 12345}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, http.StatusBadRequest, codeFrom(ErrBackendInvalidRate))
+	assert.Equal(t, http.StatusBadRequest, codeFrom(ErrBackendInvalidFrame))
+
+	// deprecated routes write the TX table and read the RX cache
+	resp = do("POST", "/slcan", `{"id":123,"data":"00"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+	assert.Equal(t, http.StatusOK, do("PUT", "/slcan/123", `{"data":"01"}`).StatusCode)
+	m, _ := db.GetTx(123)
+	assert.Equal(t, []byte{0x01}, m.Data)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/slcan/123", "").StatusCode)
+	_ = db.WriteData(Message{ID: 123, Data: []byte{0x02}})
+	assert.Equal(t, http.StatusOK, do("GET", "/slcan/123", "").StatusCode)
+	var l listRxMessagesResponse
+	req, _ = http.NewRequest("GET", srv.URL+"/slcan", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&l))
+	assert.Equal(t, 1, l.Total)
+	assert.Equal(t, []byte{0x02}, l.Messages[0].Data)
+	assert.Equal(t, http.StatusOK, do("DELETE", "/slcan/123", "").StatusCode)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/slcan/123", "").StatusCode)
+}
+
+func TestHTTPListenOnly(t *testing.T) {
+	db := NewDatabase()
+	svc := NewService(db)
+	svc = BackendMiddleware(NewBackend(db, WithListenOnly(true)))(svc)
+	mux := MakeHTTPHandler(svc, log.NewNopLogger())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	var want getStatusResponse
+	req, _ := http.NewRequest("GET", srv.URL+"/slcan/status", nil)
+	resp, _ := http.DefaultClient.Do(req)
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	err := json.Unmarshal(body, &want)
+	assert.NoError(t, err)
+	assert.Equal(t, MODE_LISTEN_ONLY, want.Status.Mode)
+
+	// transmitting is rejected without touching the database
+	jsonMsg, _ := json.Marshal(Message{ID: 124, Data: []byte("200rpm")})
+	req, _ = http.NewRequest("POST", srv.URL+"/slcan/tx", bytes.NewBuffer(jsonMsg))
+	resp, _ = http.DefaultClient.Do(req)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	req, _ = http.NewRequest("GET", srv.URL+"/slcan/tx/124", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	req, _ = http.NewRequest("POST", srv.URL+"/slcan/124/rtr", nil)
+	resp, _ = http.DefaultClient.Do(req)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestBackendMiddlewareRollback(t *testing.T) {
+	db := NewDatabase()
+	b := NewBackend(db, WithLink(NewSimulator()), WithStatusInterval(0))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, SIM_SCHEME, 0, "") }()
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_CONNECTED
+	}, time.Second, time.Millisecond)
+	svc := BackendMiddleware(b)(NewService(db))
+	assert.Equal(t, nil, svc.PostTxMessage(context.Background(), Message{ID: 0x123, Data: []byte{0x01}}))
+	cancel()
+	assert.Equal(t, nil, <-done)
+
+	// messages never transmitted are not kept
+	assert.Equal(t, ErrBackendClosed, svc.PutTxMessage(context.Background(), 0x123, Message{Data: []byte{0x02}}))
+	m, _ := db.GetTx(0x123)
+	assert.Equal(t, []byte{0x01}, m.Data)
+	assert.Equal(t, ErrBackendClosed, svc.PostTxMessage(context.Background(), Message{ID: 0x124}))
+	_, err := db.GetTx(0x124)
+	assert.Equal(t, ErrDatabaseNotFound, err)
+}
+
+func TestBackendMiddlewareGetRx(t *testing.T) {
+	db := NewDatabase()
+	s := NewSimulator()
+	b := NewBackend(db, WithLink(s), WithStatusInterval(0))
+	go b.Handler(SIM_SCHEME, 0, "")
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_CONNECTED
+	}, time.Second, time.Millisecond)
+	svc := BackendMiddleware(b)(NewService(db))
+
+	// the RX cache is read without putting anything on the bus
+	n := len(s.Sent())
+	_, err := svc.GetRxMessage(context.Background(), 0x321)
+	assert.Equal(t, ErrDatabaseNotFound, err)
+	assert.Equal(t, n, len(s.Sent()))
+}
+
+func TestTxRxHTTP(t *testing.T) {
+	d := NewDatabase()
+	srv := httptest.NewServer(MakeHTTPHandler(NewService(d), log.NewNopLogger()))
+	defer srv.Close()
+
+	do := func(method, path string, body string, v interface{}) int {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		if v != nil {
+			_ = json.NewDecoder(resp.Body).Decode(v)
+		}
+		return resp.StatusCode
+	}
+
+	// frames received and messages posted for the same ID never clobber
+	// each other
+	_ = d.WriteData(Message{ID: 0x123, Data: []byte("rx")})
+	assert.Equal(t, http.StatusOK, do("POST", "/slcan/tx", `{"id":291,"data":"7478"}`, nil))
+	_ = d.WriteData(Message{ID: 0x123, Data: []byte("rx2")})
+	var tx getTxMessageResponse
+	assert.Equal(t, http.StatusOK, do("GET", "/slcan/tx/291", "", &tx))
+	assert.Equal(t, Message{ID: 0x123, Data: []byte("tx"), Direction: DIRECTION_TX}, tx.Msg)
+	var rx getRxMessageResponse
+	assert.Equal(t, http.StatusOK, do("GET", "/slcan/rx/291", "", &rx))
+	assert.Equal(t, []byte("rx2"), rx.Msg.Data)
+	assert.Equal(t, DIRECTION_RX, rx.Msg.Direction)
+
+	var l listTxMessagesResponse
+	assert.Equal(t, http.StatusOK, do("GET", "/slcan/tx", "", &l))
+	assert.Equal(t, 1, l.Total)
+
+	// the path ID is authoritative, a different body ID is rejected
+	assert.Equal(t, http.StatusOK, do("PUT", "/slcan/tx/291", `{"data":"747832"}`, nil))
+	assert.Equal(t, http.StatusBadRequest, do("PUT", "/slcan/tx/291", `{"id":292,"data":"747832"}`, nil))
+	assert.Equal(t, http.StatusNotFound, do("PUT", "/slcan/tx/292", `{"data":"747832"}`, nil))
+
+	// removing a message from one table leaves the other alone
+	assert.Equal(t, http.StatusOK, do("DELETE", "/slcan/rx/291", "", nil))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/slcan/rx/291", "", nil))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/slcan/rx/291/history", "", nil))
+	assert.Equal(t, http.StatusOK, do("GET", "/slcan/tx/291", "", &tx))
+	assert.Equal(t, []byte("tx2"), tx.Msg.Data)
+	assert.Equal(t, http.StatusOK, do("DELETE", "/slcan/tx/291", "", nil))
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/slcan/tx/291", "", nil))
+}
+
+func TestChannelsHTTP(t *testing.T) {
+	mux := MakeChannelsHTTPHandler(map[string]IService{
+		"powertrain": NewService(NewDatabase()),
+		"body":       NewService(NewDatabase()),
+	}, "powertrain", log.NewNopLogger())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	do := func(method, path string, body string) int {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// every channel has its own database
+	assert.Equal(t, http.StatusOK, do("POST", "/slcan/body/tx", `{"id":123,"data":"6f70656e"}`))
+	assert.Equal(t, http.StatusOK, do("GET", "/slcan/body/tx/123", ""))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/slcan/powertrain/tx/123", ""))
+
+	// unscoped routes map to the default channel
+	assert.Equal(t, http.StatusNotFound, do("GET", "/slcan/tx/123", ""))
+	assert.Equal(t, http.StatusOK, do("POST", "/slcan/tx", `{"id":123,"data":"32303072706d"}`))
+	assert.Equal(t, http.StatusOK, do("GET", "/slcan/powertrain/tx/123", ""))
+	assert.Equal(t, http.StatusOK, do("DELETE", "/slcan/tx/123", ""))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/slcan/powertrain/tx/123", ""))
+	assert.Equal(t, http.StatusNotFound, do("GET", "/slcan/chassis/tx/123", ""))
+}
+
+func TestHistoryHTTP(t *testing.T) {
+	d := NewDatabase()
+	t0 := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i, v := range []string{"a", "b", "c"} {
+		_ = d.WriteData(Message{ID: 0x123, Data: []byte(v), ReceivedAt: t0.Add(time.Duration(i) * time.Second)})
+	}
+	srv := httptest.NewServer(MakeHTTPHandler(NewService(d), log.NewNopLogger()))
+	defer srv.Close()
+
+	get := func(path string) (int, getHistoryResponse) {
+		var r getHistoryResponse
+		resp, err := http.Get(srv.URL + path)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		_ = json.NewDecoder(resp.Body).Decode(&r)
+		return resp.StatusCode, r
+	}
+
+	code, r := get("/slcan/rx/291/history?limit=2")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"b", "c"}, historyData(r.History))
+	code, r = get("/slcan/rx/291/history?since=2023-06-01T12:00:00Z&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"b"}, historyData(r.History))
+
+	code, _ = get("/slcan/rx/292/history")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = get("/slcan/rx/291/history?limit=-1")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get("/slcan/rx/291/history?since=yesterday")
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	// latest message is still returned on its own
+	m, _ := NewService(d).GetRxMessage(context.Background(), 0x123)
+	assert.Equal(t, []byte("c"), m.Data)
+}
+
+func TestListMessagesHTTP(t *testing.T) {
+	d := NewDatabase()
+	for _, id := range []uint32{0x100, 0x200, 0x300} {
+		_ = d.WriteData(Message{ID: id, Data: []byte("AB")})
+	}
+	srv := httptest.NewServer(MakeHTTPHandler(NewService(d), log.NewNopLogger()))
+	defer srv.Close()
+
+	get := func(query string) (int, listRxMessagesResponse) {
+		var r listRxMessagesResponse
+		resp, err := http.Get(srv.URL + "/slcan/rx" + query)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		_ = json.NewDecoder(resp.Body).Decode(&r)
+		return resp.StatusCode, r
+	}
+
+	code, r := get("?from=0x180&sort=-id&limit=1")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 2, r.Total)
+	assert.Equal(t, 1, r.Limit)
+	assert.Equal(t, 1, len(r.Messages))
+	assert.Equal(t, uint32(0x300), r.Messages[0].ID)
+
+	code, r = get("?mask=ff&match=41")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, r.Total)
+	assert.Equal(t, DefaultListLimit, r.Limit)
+
+	code, r = get("?data=42")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, r.Total)
+
+	for _, q := range []string{"?from=0x300&to=0x100", "?type=any", "?sort=data", "?mask=ff", "?mask=zz", "?data=B", "?limit=1001", "?offset=-1"} {
+		code, _ = get(q)
+		assert.Equal(t, http.StatusBadRequest, code, q)
+	}
+}
+
+func TestDataEncodingHTTP(t *testing.T) {
+	d := NewDatabase()
+	srv := httptest.NewServer(MakeHTTPHandler(NewService(d), log.NewNopLogger()))
+	defer srv.Close()
+
+	do := func(method, path string, header http.Header, body string) (int, string) {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if header != nil {
+			req.Header = header
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		var r struct {
+			Msg json.RawMessage `json:"message"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&r)
+		var m map[string]json.RawMessage
+		_ = json.Unmarshal(r.Msg, &m)
+		return resp.StatusCode, string(m["data"])
+	}
+
+	// payloads are binary, read in any encoding
+	for _, c := range []struct {
+		path   string
+		header http.Header
+		body   string
+	}{
+		{"/slcan/tx", nil, `{"id":1,"data":"00ff0a"}`},
+		{"/slcan/tx?encoding=base64", nil, `{"id":2,"data":"AP8K"}`},
+		{"/slcan/tx", http.Header{"Content-Type": {"application/json; encoding=base64"}}, `{"id":3,"data":"AP8K"}`},
+		{"/slcan/tx", nil, `{"id":4,"data":[0,255,10]}`},
+	} {
+		code, _ := do("POST", c.path, c.header, c.body)
+		assert.Equal(t, http.StatusOK, code, c.body)
+	}
+	for id := uint32(1); id <= 4; id++ {
+		m, _ := d.GetTx(id)
+		assert.Equal(t, []byte{0x00, 0xff, 0x0a}, m.Data)
+	}
+
+	// and written as negotiated, hex by default
+	for _, c := range []struct {
+		path   string
+		header http.Header
+		data   string
+	}{
+		{"/slcan/tx/1", nil, `"00ff0a"`},
+		{"/slcan/tx/1?encoding=base64", nil, `"AP8K"`},
+		{"/slcan/tx/1", http.Header{"Accept": {"application/json; encoding=array"}}, `[0,255,10]`},
+		{"/slcan/tx/1?encoding=hex", http.Header{"Accept": {"application/json; encoding=array"}}, `"00ff0a"`},
+	} {
+		code, data := do("GET", c.path, c.header, "")
+		assert.Equal(t, http.StatusOK, code, c.path)
+		assert.Equal(t, c.data, data, c.path)
+	}
+
+	for _, c := range []struct {
+		path   string
+		header http.Header
+		body   string
+	}{
+		{"/slcan/tx?encoding=ascii", nil, `{"id":5,"data":"00"}`},
+		{"/slcan/tx", http.Header{"Accept": {"application/json; encoding=ascii"}}, `{"id":5,"data":"00"}`},
+		{"/slcan/tx", nil, `{"id":5,"data":"AP8K"}`},
+		{"/slcan/tx", nil, `{"id":5,"data":[256]}`},
+	} {
+		code, _ := do("POST", c.path, c.header, c.body)
+		assert.Equal(t, http.StatusBadRequest, code, c.body)
+	}
 }
@@ -0,0 +1,336 @@
+package slcansvc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSimInvalidProfile = errors.New("Simulator: invalid traffic profile")
+)
+
+const SIM_SCHEME = "sim://"
+
+const (
+	SIM_APPLICATION = "application"
+	SIM_REBOOTING   = "rebooting"
+	SIM_RECOVERY    = "recovery"
+)
+
+// SimFrame is a frame of a simulator traffic profile, received At after
+// the CAN channel is opened. Frame is a SLCAN frame without terminator,
+// e.g. t1232AABB.
+type SimFrame struct {
+	At    time.Duration
+	Frame string
+}
+
+// Simulator is a Link to an emulated SLCAN device. It acknowledges
+// commands, loops transmitted frames back, replays a traffic profile
+// while the CAN channel is open and emulates the "bbbbbb" reboot
+// handshake into MCUboot serial recovery. Reopening the link boots the
+// device back into the application, as after a firmware update.
+type Simulator struct {
+	mtx     sync.Mutex
+	open    bool
+	gone    bool
+	state   string
+	channel bool
+	listen  bool
+	tstamp  bool
+	flags   byte
+	opened  time.Time
+	line    []byte
+	rx      bytes.Buffer
+	sent    []string
+	profile []SimFrame
+	next    int
+	cycle   time.Duration
+}
+
+// NewSimulator returns a simulated SLCAN device replaying the profile,
+// which repeats after its last frame.
+func NewSimulator(profile ...SimFrame) *Simulator {
+	return &Simulator{state: SIM_APPLICATION, profile: profile}
+}
+
+// ParseSimProfile reads a traffic profile, one frame per line as the
+// offset in milliseconds followed by the SLCAN frame, e.g. "100 t1232AABB".
+// Empty lines and lines starting with '#' are skipped.
+func ParseSimProfile(r io.Reader) ([]SimFrame, error) {
+	var p []SimFrame
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		l := strings.TrimSpace(sc.Text())
+		if l == "" || l[0] == '#' {
+			continue
+		}
+		f := strings.Fields(l)
+		if len(f) != 2 {
+			return nil, ErrSimInvalidProfile
+		}
+		ms, err := strconv.ParseUint(f[0], 10, 32)
+		if err != nil {
+			return nil, ErrSimInvalidProfile
+		}
+		if _, err := decapsSlcanFrame([]byte(f[1] + "\r")); err != nil {
+			return nil, ErrSimInvalidProfile
+		}
+		at := time.Duration(ms) * time.Millisecond
+		if len(p) > 0 && at < p[len(p)-1].At {
+			return nil, ErrSimInvalidProfile
+		}
+		p = append(p, SimFrame{At: at, Frame: f[1]})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Open boots the simulated device into the application. A port naming a
+// profile file, e.g. sim://traffic.txt, loads the profile unless the
+// simulator was given one.
+func (s *Simulator) Open(port string, baud int) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.gone {
+		return ErrLinkDown
+	}
+	if path := strings.TrimPrefix(port, SIM_SCHEME); path != "" && path != port && s.profile == nil {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if s.profile, err = ParseSimProfile(f); err != nil {
+			return err
+		}
+	}
+	s.open = true
+	s.state = SIM_APPLICATION
+	s.channel, s.listen, s.tstamp = false, false, false
+	s.line = s.line[:0]
+	s.rx.Reset()
+	return nil
+}
+
+func (s *Simulator) Read(p []byte) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.open {
+		return 0, ErrLinkClosed
+	}
+	s.replay(time.Now())
+	if s.rx.Len() == 0 {
+		// Behave like an idle serial port with a read timeout
+		s.mtx.Unlock()
+		time.Sleep(time.Millisecond)
+		s.mtx.Lock()
+		return 0, nil
+	}
+	return s.rx.Read(p)
+}
+
+func (s *Simulator) Write(p []byte) (int, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.open {
+		return 0, ErrLinkClosed
+	}
+	for _, c := range p {
+		switch c {
+		case 0:
+		case '\r':
+			s.command(string(s.line))
+			s.line = s.line[:0]
+		default:
+			s.line = append(s.line, c)
+		}
+	}
+	// MCUboot is prompted into serial recovery without terminator
+	if s.state == SIM_REBOOTING && string(s.line) == "bbbbbb" {
+		s.state = SIM_RECOVERY
+		s.line = s.line[:0]
+	}
+	return len(p), nil
+}
+
+func (s *Simulator) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if !s.open {
+		return ErrLinkClosed
+	}
+	s.open = false
+	return nil
+}
+
+func (s *Simulator) Flush() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.rx.Reset()
+	return nil
+}
+
+func (s *Simulator) Reconfigure(baud int) error {
+	return nil
+}
+
+// Unplug emulates the device going away, the link fails and cannot be
+// opened again until Plug.
+func (s *Simulator) Unplug() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.gone = true
+	s.open = false
+}
+
+// Plug emulates the device coming back, with its channel closed.
+func (s *Simulator) Plug() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.gone = false
+}
+
+// Inject has the simulated device receive a SLCAN frame from the bus,
+// e.g. t1232AABB, or send any other line such as an error frame.
+func (s *Simulator) Inject(frame string) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.receive(frame)
+}
+
+// SetStatus sets the status flags reported to the "F" command.
+func (s *Simulator) SetStatus(flags byte) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.flags = flags
+}
+
+// Sent returns the commands and frames written to the simulated device,
+// without terminators.
+func (s *Simulator) Sent() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return append([]string{}, s.sent...)
+}
+
+// State returns the simulated device state, SIM_APPLICATION, or
+// SIM_REBOOTING and SIM_RECOVERY during the reboot handshake.
+func (s *Simulator) State() string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.state
+}
+
+// command answers a line written to the simulated device
+func (s *Simulator) command(l string) {
+	s.sent = append(s.sent, l)
+	if s.state != SIM_APPLICATION || l == "" {
+		// MCUboot does not speak SLCAN
+		return
+	}
+	if l == "bbbbbb" {
+		s.state = SIM_REBOOTING
+		s.channel = false
+		return
+	}
+
+	ack, nack := "\r", "\a"
+	switch l[0] {
+	case 't', 'r', 'd', 'b', 'T', 'R', 'D', 'B':
+		if !s.channel || s.listen {
+			s.rx.WriteString(nack)
+			return
+		}
+		if _, err := decapsSlcanFrame([]byte(l + "\r")); err != nil {
+			s.rx.WriteString(nack)
+			return
+		}
+		if l[0] >= 'a' {
+			s.rx.WriteString("z\r")
+		} else {
+			s.rx.WriteString("Z\r")
+		}
+		// Loop transmitted frames back
+		s.receive(l)
+	case 'O', 'L':
+		if s.channel {
+			s.rx.WriteString(nack)
+			return
+		}
+		s.channel, s.listen = true, l[0] == 'L'
+		s.opened, s.next, s.cycle = time.Now(), 0, 0
+		s.rx.WriteString(ack)
+	case 'C':
+		if !s.channel {
+			s.rx.WriteString(nack)
+			return
+		}
+		s.channel = false
+		s.rx.WriteString(ack)
+	case 'S', 's', 'Y', 'M', 'm', 'Z':
+		if s.channel {
+			s.rx.WriteString(nack)
+			return
+		}
+		if l[0] == 'Z' {
+			s.tstamp = l == "Z1"
+		}
+		s.rx.WriteString(ack)
+	case 'V':
+		s.rx.WriteString("V1013\r")
+	case 'v':
+		s.rx.WriteString("vsim-1.0.0\r")
+	case 'N':
+		s.rx.WriteString("NSIM0\r")
+	case 'F':
+		if !s.channel {
+			s.rx.WriteString(nack)
+			return
+		}
+		s.rx.WriteString(fmt.Sprintf("F%02X\r", s.flags))
+		s.flags = 0
+	default:
+		s.rx.WriteString(nack)
+	}
+}
+
+// receive queues a frame received from the bus for the host
+func (s *Simulator) receive(frame string) {
+	if s.channel && s.tstamp && len(frame) > 0 && frame[0] != 'e' && frame[0] != 's' {
+		ms := time.Since(s.opened).Milliseconds() % slcanTimestampWrap
+		frame += fmt.Sprintf("%04X", ms)
+	}
+	s.rx.WriteString(frame + "\r")
+}
+
+// replay queues the profile frames due since the channel was opened
+func (s *Simulator) replay(now time.Time) {
+	if !s.channel || len(s.profile) == 0 {
+		return
+	}
+	last := s.profile[len(s.profile)-1].At
+	for {
+		f := s.profile[s.next]
+		if s.opened.Add(s.cycle + f.At).After(now) {
+			return
+		}
+		s.receive(f.Frame)
+		s.next += 1
+		if s.next == len(s.profile) {
+			// Profile repeats after its last frame, at least a millisecond later
+			s.next = 0
+			s.cycle += last + time.Millisecond
+		}
+	}
+}
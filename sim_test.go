@@ -0,0 +1,334 @@
+package slcansvc
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// simExchange writes a command to the simulator and returns its reply
+func simExchange(s *Simulator, cmd string) string {
+	_, _ = s.Write([]byte(cmd))
+	rb := make([]byte, 256)
+	n, _ := s.Read(rb)
+	return string(rb[:n])
+}
+
+func TestSimulatorCommands(t *testing.T) {
+	s := NewSimulator()
+	assert.Equal(t, nil, s.Open(SIM_SCHEME, 0))
+
+	// configuration is only accepted while the channel is closed
+	assert.Equal(t, "\a", simExchange(s, "C\r"))
+	assert.Equal(t, "\r", simExchange(s, "S6\r"))
+	assert.Equal(t, "\a", simExchange(s, "t1232AABB\r\x00"))
+	assert.Equal(t, "\r", simExchange(s, "O\r"))
+	assert.Equal(t, "\a", simExchange(s, "S6\r"))
+
+	// transmitted frames are acknowledged and looped back
+	assert.Equal(t, "z\rt12324142\r", simExchange(s, "t12324142\r\x00"))
+	assert.Equal(t, "Z\rR123456782\r", simExchange(s, "R123456782\r\x00"))
+	assert.Equal(t, "\a", simExchange(s, "t12\r"))
+
+	// queries
+	assert.Equal(t, "V1013\r", simExchange(s, "V\r"))
+	assert.Equal(t, "NSIM0\r", simExchange(s, "N\r"))
+	s.SetStatus(0x80)
+	assert.Equal(t, "F80\r", simExchange(s, "F\r"))
+	assert.Equal(t, "F00\r", simExchange(s, "F\r"))
+
+	// listen-only channel never transmits
+	assert.Equal(t, "\r", simExchange(s, "C\r"))
+	assert.Equal(t, "\r", simExchange(s, "L\r"))
+	assert.Equal(t, "\a", simExchange(s, "t12324142\r\x00"))
+
+	assert.Equal(t, []string{"C", "S6", "t1232AABB", "O", "S6", "t12324142", "R123456782", "t12",
+		"V", "N", "F", "F", "C", "L", "t12324142"}, s.Sent())
+}
+
+func TestSimulatorReboot(t *testing.T) {
+	s := NewSimulator()
+	assert.Equal(t, nil, s.Open(SIM_SCHEME, 0))
+	assert.Equal(t, "\r", simExchange(s, "O\r"))
+
+	// device reboots into MCUboot, which is prompted into serial recovery
+	assert.Equal(t, "", simExchange(s, "bbbbbb\r\x00"))
+	assert.Equal(t, SIM_REBOOTING, s.State())
+	assert.Equal(t, "", simExchange(s, "V\r"))
+	assert.Equal(t, "", simExchange(s, "bbbbbb"))
+	assert.Equal(t, SIM_RECOVERY, s.State())
+
+	// reopening boots the updated application with its channel closed
+	assert.Equal(t, nil, s.Close())
+	assert.Equal(t, nil, s.Open(SIM_SCHEME, 0))
+	assert.Equal(t, SIM_APPLICATION, s.State())
+	assert.Equal(t, "\a", simExchange(s, "C\r"))
+}
+
+func TestParseSimProfile(t *testing.T) {
+	p, err := ParseSimProfile(strings.NewReader("# engine speed\n0 t1232AABB\n\n100 T123456781FF\n"))
+	assert.Equal(t, []SimFrame{
+		{At: 0, Frame: "t1232AABB"},
+		{At: 100 * time.Millisecond, Frame: "T123456781FF"},
+	}, p)
+	assert.Equal(t, nil, err)
+
+	for _, s := range []string{"t1232AABB", "x t1232AABB", "0 x123", "100 t1230\n50 t1230"} {
+		_, err = ParseSimProfile(strings.NewReader(s))
+		assert.Equal(t, ErrSimInvalidProfile, err, s)
+	}
+}
+
+func TestSimulatorHandler(t *testing.T) {
+	s := NewSimulator(SimFrame{At: 0, Frame: "t33324142"}, SimFrame{At: 10 * time.Millisecond, Frame: "t33324344"})
+	db := NewDatabase()
+	b := NewBackend(db, WithLink(s), WithStatusInterval(time.Millisecond), WithTimestamp(true))
+	go b.Handler(SIM_SCHEME, 0, "")
+
+	assert.Eventually(t, func() bool {
+		d, _ := b.GetDeviceInfo()
+		return d.Serial == "SIM0"
+	}, time.Second, time.Millisecond)
+	d, _ := b.GetDeviceInfo()
+	assert.Equal(t, "sim-1.0.0", d.Version)
+
+	// profile is replayed, repeatedly
+	assert.Eventually(t, func() bool {
+		m, err := db.GetRx(0x333)
+		return err == nil && string(m.Data) == "CD" && m.Timestamp != nil
+	}, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		m, _ := db.GetRx(0x333)
+		return string(m.Data) == "AB"
+	}, time.Second, time.Millisecond)
+
+	// transmitted frames are looped back
+	assert.Equal(t, nil, b.PostMessage(Message{ID: 0x222, Data: []byte("AB")}))
+	assert.Eventually(t, func() bool {
+		m, err := db.GetRx(0x222)
+		return err == nil && string(m.Data) == "AB"
+	}, time.Second, time.Millisecond)
+
+	// status flags are polled
+	s.SetStatus(0x20)
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Flags.ErrorPassive
+	}, time.Second, time.Millisecond)
+}
+
+func TestSimulatorBusOff(t *testing.T) {
+	s := NewSimulator()
+	b := NewBackend(NewDatabase(), WithLink(s), WithStatusInterval(time.Millisecond), WithBusOffRecovery(true))
+	go b.Handler(SIM_SCHEME, 0, "")
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_CONNECTED
+	}, time.Second, time.Millisecond)
+
+	// bus error interrupts are reported, but the channel is left alone
+	s.SetStatus(0x80)
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Flags.BusError
+	}, time.Second, time.Millisecond)
+	st, _ := b.GetStatus()
+	assert.Equal(t, 0, st.Recoveries)
+
+	// bus-off state frames have the channel reopened
+	s.Inject("sb255000")
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.State == STATE_BUS_OFF && st.Recoveries == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestSimulatorReconnect(t *testing.T) {
+	s := NewSimulator()
+	b := NewBackend(NewDatabase(), WithLink(s), WithStatusInterval(0), WithBitrate(Bitrate{Rate: 250000}),
+		WithReconnectBackoff(time.Millisecond, 10*time.Millisecond))
+	go b.Handler(SIM_SCHEME, 0, "")
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_CONNECTED
+	}, time.Second, time.Millisecond)
+
+	// requests are turned away while the device is gone
+	s.Unplug()
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_RECONNECTING
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, ErrBackendReconnecting, b.PostMessage(Message{ID: 0x444, Data: []byte("AB")}))
+
+	// device is initialised with its configuration again once back
+	s.Plug()
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_CONNECTED && st.Reconnects == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, nil, b.PostMessage(Message{ID: 0x444, Data: []byte("AB")}))
+	sent := strings.Join(s.Sent(), ",")
+	assert.Equal(t, 2, strings.Count(sent, "C,S5,O,V,v,N"), sent)
+}
+
+func TestSimulatorShutdown(t *testing.T) {
+	s := NewSimulator()
+	b := NewBackend(NewDatabase(), WithLink(s), WithStatusInterval(0))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, SIM_SCHEME, 0, "") }()
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_CONNECTED
+	}, time.Second, time.Millisecond)
+	_, err := b.AddJob(Job{Message: Message{ID: 0x222, Data: []byte("AB")}, Period: 1000})
+	assert.Equal(t, nil, err)
+
+	// frames accepted before cancellation are transmitted, others turned away
+	posted := make(chan error, 10)
+	for i := 0; i < cap(posted); i++ {
+		go func() { posted <- b.PostMessage(Message{ID: 0x111, Data: []byte("AB")}) }()
+	}
+	cancel()
+	sent := 0
+	for i := 0; i < cap(posted); i++ {
+		err := <-posted
+		assert.Contains(t, []error{nil, ErrBackendOnhold, ErrBackendClosed}, err)
+		if err == nil {
+			sent += 1
+		}
+	}
+	assert.Equal(t, nil, <-done)
+
+	// channel is closed before the link
+	cmds := s.Sent()
+	assert.Equal(t, sent, strings.Count(strings.Join(cmds, ","), "t1112"))
+	assert.Equal(t, "C", cmds[len(cmds)-1])
+	_, err = s.Write([]byte("V\r"))
+	assert.Equal(t, ErrLinkClosed, err)
+	assert.Equal(t, ErrBackendClosed, b.PostMessage(Message{ID: 0x111, Data: []byte("AB")}))
+	st, _ := b.GetStatus()
+	assert.Equal(t, LINK_CLOSED, st.Link)
+
+	// jobs are not started once the backend is closed
+	j := Job{Message: Message{ID: 0x222, Data: []byte("AB")}, Period: 10}
+	_, err = b.AddJob(j)
+	assert.Equal(t, ErrBackendClosed, err)
+	_, err = b.UpdateJob(1, j)
+	assert.Equal(t, ErrBackendClosed, err)
+	_, err = b.StartJob(1)
+	assert.Equal(t, ErrBackendClosed, err)
+	jobs, _ := b.GetJobs()
+	assert.Equal(t, JOB_STOPPED, jobs[0].State)
+}
+
+func TestSimulatorRebootHold(t *testing.T) {
+	s := NewSimulator()
+	b := NewBackend(NewDatabase(), WithLink(s), WithStatusInterval(0))
+	go b.Run(context.Background(), SIM_SCHEME, 0, "")
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_CONNECTED
+	}, time.Second, time.Millisecond)
+
+	// frames posted while the device reboots are turned away rather than
+	// blocking Handler from taking the hold
+	assert.Equal(t, nil, b.Reboot())
+	posted := make(chan error, 1)
+	go func() { posted <- b.PostMessage(Message{ID: 0x111, Data: []byte("AB")}) }()
+	select {
+	case err := <-posted:
+		assert.Equal(t, ErrBackendOnhold, err)
+	case <-time.After(time.Second):
+		t.Fatal("PostMessage did not return")
+	}
+	st, _ := b.GetStatus()
+	assert.Equal(t, LINK_REBOOTING, st.Link)
+}
+
+func TestSimulatorUnplugged(t *testing.T) {
+	s := NewSimulator()
+	s.Unplug()
+	b := NewBackend(NewDatabase(), WithLink(s), WithStatusInterval(0))
+	assert.Equal(t, ErrBackendPortOpen, b.Run(context.Background(), SIM_SCHEME, 0, ""))
+
+	// requests to a channel that never came up are turned away
+	assert.Equal(t, ErrBackendClosed, b.PostMessage(Message{ID: 0x111, Data: []byte("AB")}))
+	assert.Equal(t, ErrBackendClosed, b.Reboot())
+	st, _ := b.GetStatus()
+	assert.Equal(t, LINK_CLOSED, st.Link)
+}
+
+func TestSimulatorShutdownReconnecting(t *testing.T) {
+	s := NewSimulator()
+	b := NewBackend(NewDatabase(), WithLink(s), WithStatusInterval(0), WithReconnectBackoff(time.Hour, time.Hour))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- b.Run(ctx, SIM_SCHEME, 0, "") }()
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_CONNECTED
+	}, time.Second, time.Millisecond)
+
+	// backoff is cut short
+	s.Unplug()
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_RECONNECTING
+	}, time.Second, time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.Equal(t, nil, err)
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return")
+	}
+	assert.Equal(t, ErrBackendClosed, b.PostMessage(Message{ID: 0x111, Data: []byte("AB")}))
+}
+
+func TestSimulatorJobs(t *testing.T) {
+	s := NewSimulator()
+	b := NewBackend(NewDatabase(), WithLink(s), WithStatusInterval(0),
+		WithReconnectBackoff(time.Millisecond, 10*time.Millisecond))
+	go b.Handler(SIM_SCHEME, 0, "")
+	assert.Eventually(t, func() bool {
+		st, _ := b.GetStatus()
+		return st.Link == LINK_CONNECTED
+	}, time.Second, time.Millisecond)
+
+	// invalid frames are rejected up front
+	_, err := b.AddJob(Job{Message: Message{ID: 0x555, Data: []byte("012345678")}, Period: 1})
+	assert.Equal(t, ErrBackendInvalidData, err)
+
+	j, err := b.AddJob(Job{Message: Message{ID: 0x555, Data: []byte("AB")}, Period: 2})
+	assert.Equal(t, nil, err)
+	assert.Eventually(t, func() bool {
+		return strings.Count(strings.Join(s.Sent(), ","), "t55524142") >= 3
+	}, time.Second, time.Millisecond)
+
+	// job is paused while the device is gone, and resumes once back
+	s.Unplug()
+	assert.Eventually(t, func() bool {
+		j, _ = b.GetJob(j.ID)
+		return j.State == JOB_PAUSED
+	}, time.Second, time.Millisecond)
+	s.Plug()
+	assert.Eventually(t, func() bool {
+		j, _ = b.GetJob(j.ID)
+		return j.State == JOB_RUNNING
+	}, time.Second, time.Millisecond)
+	sent := j.Sent
+	assert.Eventually(t, func() bool {
+		j, _ = b.GetJob(j.ID)
+		return j.Sent > sent
+	}, time.Second, time.Millisecond)
+
+	j, err = b.StopJob(j.ID)
+	assert.Equal(t, JOB_STOPPED, j.State)
+	assert.Equal(t, nil, err)
+	assert.Equal(t, nil, b.DeleteJob(j.ID))
+}
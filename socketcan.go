@@ -0,0 +1,442 @@
+//go:build linux
+
+package slcansvc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// canFrame mirrors struct canfd_frame of linux/can.h, whose first 16 bytes
+// are a struct can_frame
+type canFrame struct {
+	ID    uint32
+	Len   uint8
+	Flags uint8
+	_     [2]uint8
+	Data  [64]byte
+}
+
+const (
+	canFrameSize   = 16
+	canFDFrameSize = int(unsafe.Sizeof(canFrame{}))
+	canFDBRS       = 0x01
+	canFDFDF       = 0x04
+)
+
+// Error frame classes and details of linux/can/error.h
+const (
+	canErrLostArb    = 0x00000002
+	canErrCtrl       = 0x00000004
+	canErrProt       = 0x00000008
+	canErrAck        = 0x00000020
+	canErrBusOff     = 0x00000040
+	canErrRestarted  = 0x00000100
+	canErrCnt        = 0x00000200
+	canErrCtrlRxOver = 0x01
+	canErrCtrlTxOver = 0x02
+	canErrCtrlRxWarn = 0x04
+	canErrCtrlTxWarn = 0x08
+	canErrCtrlRxPass = 0x10
+	canErrCtrlTxPass = 0x20
+	canErrCtrlActive = 0x40
+	canErrProtForm   = 0x02
+	canErrProtStuff  = 0x04
+	canErrProtBit0   = 0x08
+	canErrProtBit1   = 0x10
+	canErrProtLocCrc = 0x08
+	canErrProtLocDel = 0x18
+	canErrMask       = 0x1fffffff
+)
+
+// canReadTimeout bounds how long the receive loop blocks on the socket,
+// and so how long it takes to notice cancellation
+const canReadTimeout = time.Second
+
+// socketCANBackend is an IBackend over a Linux SocketCAN raw socket, e.g.
+// a native CAN interface or one attached with slcand. The interface is
+// configured with "ip link", so the SLCAN specific bitrate, acceptance,
+// mode and device requests are not supported. It shares the bookkeeping
+// of filters, waiters, status and error log with Backend.
+type socketCANBackend struct {
+	*Backend
+
+	wmtx sync.Mutex
+	fd   int
+}
+
+// NewSocketCANBackend returns a backend which sends and receives frames
+// through a SocketCAN interface, given to Handler as "can:<interface>",
+// writing the frames received to d.
+func NewSocketCANBackend(d Database, options ...BackendOption) IBackend {
+	b := &socketCANBackend{Backend: NewBackend(d, options...).(*Backend), fd: -1}
+	// Cyclic jobs transmit through the socket
+	b.sched = newScheduler(b.PostMessage, b.GetStatus)
+	return b
+}
+
+func (b *socketCANBackend) Handler(port string, baud int, url string) error {
+	return b.Run(context.Background(), port, baud, url)
+}
+
+// Run receives frames from the SocketCAN interface until ctx is cancelled.
+// Frames are written synchronously, so nothing is left to drain before the
+// socket is closed. A socket failing after it was opened is reopened with
+// backoff, the jobs pausing meanwhile, as serial links are.
+func (b *socketCANBackend) Run(ctx context.Context, port string, baud int, url string) error {
+	// Requests are turned away once the interface is gone for good
+	defer func() {
+		b.setLink(LINK_CLOSED)
+		b.sched.close()
+	}()
+	c := b.getConfig()
+	if c.bitrate != (Bitrate{}) || c.acceptance != (Acceptance{}) || c.tstamp || c.listenOnly {
+		return ErrBackendUnsupported
+	}
+
+	if err := b.dial(port); err != nil {
+		return err
+	}
+	defer b.hangup()
+	b.setLink(LINK_CONNECTED)
+
+	var frame canFrame
+	buf := (*[unsafe.Sizeof(frame)]byte)(unsafe.Pointer(&frame))[:]
+	for {
+		if ctx.Err() != nil {
+			b.logger.Log("event", "shutdown", "link", LINK_CLOSED)
+			return nil
+		}
+		b.wmtx.Lock()
+		fd := b.fd
+		b.wmtx.Unlock()
+		n, err := unix.Read(fd, buf)
+		if err == unix.EAGAIN || err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			b.logger.Log("event", "disconnect", "err", err)
+			if err = b.reconnect(ctx, port); err != nil {
+				b.logger.Log("event", "shutdown", "link", LINK_CLOSED)
+				return nil
+			}
+			continue
+		}
+		if n != canFrameSize && n != canFDFrameSize {
+			continue
+		}
+		b.receive(frame, n == canFDFrameSize)
+	}
+}
+
+// dial opens a raw socket on the interface and installs it along with the
+// filters
+func (b *socketCANBackend) dial(port string) error {
+	ifi, err := net.InterfaceByName(strings.TrimPrefix(port, SOCKETCAN_SCHEME))
+	if err != nil {
+		return ErrBackendPortOpen
+	}
+	fd, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, unix.CAN_RAW)
+	if err != nil {
+		return ErrBackendPortOpen
+	}
+	if err = unix.Bind(fd, &unix.SockaddrCAN{Ifindex: ifi.Index}); err != nil {
+		unix.Close(fd)
+		return ErrBackendPortOpen
+	}
+	// CAN FD frames are only available on CAN FD capable interfaces
+	_ = unix.SetsockoptInt(fd, unix.SOL_CAN_RAW, unix.CAN_RAW_FD_FRAMES, 1)
+	if err = unix.SetsockoptInt(fd, unix.SOL_CAN_RAW, unix.CAN_RAW_ERR_FILTER, canErrMask); err != nil {
+		unix.Close(fd)
+		return ErrBackendPortOpen
+	}
+	tv := unix.NsecToTimeval(canReadTimeout.Nanoseconds())
+	if err = unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return ErrBackendPortOpen
+	}
+
+	b.wmtx.Lock()
+	defer b.wmtx.Unlock()
+	b.fd = fd
+	f, _ := b.GetFilters()
+	if err = b.applyFilters(f); err != nil {
+		unix.Close(fd)
+		b.fd = -1
+		return err
+	}
+	return nil
+}
+
+// hangup closes the socket, frames posted meanwhile are turned away
+func (b *socketCANBackend) hangup() {
+	b.wmtx.Lock()
+	defer b.wmtx.Unlock()
+	if b.fd >= 0 {
+		unix.Close(b.fd)
+		b.fd = -1
+	}
+}
+
+// reconnect reopens the socket after a read error, until it succeeds or
+// ctx is cancelled
+func (b *socketCANBackend) reconnect(ctx context.Context, port string) error {
+	b.setLink(LINK_RECONNECTING)
+	b.hangup()
+	if err := b.retry(ctx, func() error { return b.dial(port) }); err != nil {
+		return err
+	}
+	b.mtx.Lock()
+	b.status.Reconnects += 1
+	b.mtx.Unlock()
+	return nil
+}
+
+// receive stores a received data frame or logs an error frame
+func (b *socketCANBackend) receive(f canFrame, fd bool) {
+	if f.ID&unix.CAN_ERR_FLAG != 0 {
+		// Error frames only report the classes which occurred, the flags
+		// of the others stay as they were
+		st, _ := b.GetStatus()
+		e, flags := decapsCANError(f, st.Flags)
+		e.ReceivedAt = time.Now()
+		b.errlog.add(e)
+		b.updateStatus(flags)
+		// Bus-off is recovered from by the interface, e.g. with restart-ms
+		if e.State != "" {
+			b.updateState(e.State)
+		}
+		return
+	}
+	m := decapsCANFrame(f, fd)
+	// Remote requests from other nodes carry no data worth storing
+	if !m.RTR && b.accept(m) {
+		m.ReceivedAt, m.Direction = time.Now(), DIRECTION_RX
+		_ = b.db.WriteData(m)
+		b.notify(m)
+	}
+}
+
+func (b *socketCANBackend) PostMessage(m Message) error {
+	f, n, err := encapsCANFrame(m)
+	if err != nil {
+		return err
+	}
+	b.wmtx.Lock()
+	defer b.wmtx.Unlock()
+	if b.fd < 0 {
+		return b.unavailable()
+	}
+	buf := (*[unsafe.Sizeof(f)]byte)(unsafe.Pointer(&f))[:n]
+	if _, err = unix.Write(b.fd, buf); err != nil {
+		return ErrBackendPortWrite
+	}
+	return nil
+}
+
+func (b *socketCANBackend) RemoteRequest(id int, dlc int, wait time.Duration) (Message, error) {
+	return b.remoteRequest(b.PostMessage, id, dlc, wait)
+}
+
+func (b *socketCANBackend) SetFilters(f []Filter) error {
+	if err := b.Backend.SetFilters(f); err != nil {
+		return err
+	}
+	b.wmtx.Lock()
+	defer b.wmtx.Unlock()
+	if b.fd < 0 {
+		return nil
+	}
+	return b.applyFilters(f)
+}
+
+// applyFilters programs the filters into the socket with CAN_RAW_FILTER,
+// ranges are left to the software filters
+func (b *socketCANBackend) applyFilters(f []Filter) error {
+	if err := unix.SetsockoptCanRawFilter(b.fd, unix.SOL_CAN_RAW, unix.CAN_RAW_FILTER, canRawFilters(f)); err != nil {
+		return ErrBackendInvalidFilter
+	}
+	return nil
+}
+
+func (b *socketCANBackend) Reboot() error {
+	return ErrBackendUnsupported
+}
+
+func (b *socketCANBackend) Unlock() error {
+	return ErrBackendUnsupported
+}
+
+func (b *socketCANBackend) SetBitrate(br Bitrate) error {
+	return ErrBackendUnsupported
+}
+
+func (b *socketCANBackend) SetAcceptance(a Acceptance) error {
+	return ErrBackendUnsupported
+}
+
+func (b *socketCANBackend) SetMode(mode string) error {
+	return ErrBackendUnsupported
+}
+
+func (b *socketCANBackend) GetDeviceInfo() (DeviceInfo, error) {
+	return DeviceInfo{}, ErrBackendUnsupported
+}
+
+func (b *socketCANBackend) QueryDeviceInfo() (DeviceInfo, error) {
+	return DeviceInfo{}, ErrBackendUnsupported
+}
+
+// canRawFilters maps filters to CAN_RAW_FILTER entries, an empty list
+// receives every frame
+func canRawFilters(f []Filter) []unix.CanFilter {
+	if len(f) == 0 {
+		return []unix.CanFilter{{Id: 0, Mask: 0}}
+	}
+	cf := make([]unix.CanFilter, 0, len(f))
+	for _, i := range f {
+		id, mask := i.ID&i.Mask, i.Mask
+		switch i.Type {
+		case FILTER_STANDARD:
+			mask |= unix.CAN_EFF_FLAG
+		case FILTER_EXTENDED:
+			id |= unix.CAN_EFF_FLAG
+			mask |= unix.CAN_EFF_FLAG
+		}
+		cf = append(cf, unix.CanFilter{Id: id, Mask: mask})
+	}
+	return cf
+}
+
+// encapsCANFrame converts a message into a CAN frame and the number of
+// bytes to write, a struct can_frame or a struct canfd_frame
+func encapsCANFrame(m Message) (canFrame, int, error) {
+	var f canFrame
+	if m.ID > CAN_ID_MAX {
+		return f, 0, ErrBackendInvalidID
+	}
+	if (m.RTR && m.FD) || (m.BRS && !m.FD) {
+		return f, 0, ErrBackendInvalidFrame
+	}
+	f.ID = m.ID
+	if m.Extended || m.ID > unix.CAN_SFF_MASK {
+		f.ID |= unix.CAN_EFF_FLAG
+	}
+
+	switch {
+	case m.RTR:
+		if m.DLC < 0 || m.DLC > 8 {
+			return f, 0, ErrBackendInvalidData
+		}
+		f.ID |= unix.CAN_RTR_FLAG
+		f.Len = uint8(m.DLC)
+		return f, canFrameSize, nil
+	case m.FD:
+		if slcanFDDlc(len(m.Data)) < 0 {
+			return f, 0, ErrBackendInvalidData
+		}
+		f.Flags = canFDFDF
+		if m.BRS {
+			f.Flags |= canFDBRS
+		}
+		f.Len = uint8(copy(f.Data[:], m.Data))
+		return f, canFDFrameSize, nil
+	default:
+		if len(m.Data) > 8 {
+			return f, 0, ErrBackendInvalidData
+		}
+		f.Len = uint8(copy(f.Data[:], m.Data))
+		return f, canFrameSize, nil
+	}
+}
+
+// decapsCANFrame converts a received CAN frame into a message
+func decapsCANFrame(f canFrame, fd bool) Message {
+	var m Message
+	m.Extended = f.ID&unix.CAN_EFF_FLAG != 0
+	m.RTR = f.ID&unix.CAN_RTR_FLAG != 0
+	m.FD = fd
+	m.BRS = fd && f.Flags&canFDBRS != 0
+	if m.Extended {
+		m.ID = f.ID & unix.CAN_EFF_MASK
+	} else {
+		m.ID = f.ID & unix.CAN_SFF_MASK
+	}
+	if m.RTR {
+		m.DLC = int(f.Len)
+		return m
+	}
+	m.Data = append([]byte(nil), f.Data[:f.Len]...)
+	return m
+}
+
+// decapsCANError converts an error frame into an error event and merges
+// the controller status it reports into the flags s. Error frames carry
+// only the classes which occurred, so the flags of the others are kept.
+func decapsCANError(f canFrame, s StatusFlags) (ErrorEvent, StatusFlags) {
+	var e ErrorEvent
+	d := f.Data[:8]
+
+	if f.ID&canErrAck != 0 {
+		e.Errors = append(e.Errors, ERROR_ACK)
+	}
+	if f.ID&canErrLostArb != 0 {
+		s.ArbitrationLost = true
+	}
+	if f.ID&canErrProt != 0 {
+		for _, c := range []struct {
+			bit   byte
+			class string
+		}{
+			{canErrProtBit0, ERROR_BIT0},
+			{canErrProtBit1, ERROR_BIT1},
+			{canErrProtForm, ERROR_FORM},
+			{canErrProtStuff, ERROR_STUFF},
+		} {
+			if d[2]&c.bit != 0 {
+				e.Errors = append(e.Errors, c.class)
+			}
+		}
+		if d[3] == canErrProtLocCrc || d[3] == canErrProtLocDel {
+			e.Errors = append(e.Errors, ERROR_CRC)
+		}
+	}
+	if f.ID&canErrCtrl != 0 {
+		if d[1]&canErrCtrlRxOver != 0 {
+			e.Errors = append(e.Errors, ERROR_RX_OVERRUN)
+			s.DataOverrun = true
+		}
+		if d[1]&canErrCtrlTxOver != 0 {
+			e.Errors = append(e.Errors, ERROR_TX_OVERRUN)
+		}
+		switch {
+		case d[1]&(canErrCtrlRxPass|canErrCtrlTxPass) != 0:
+			e.State = STATE_PASSIVE
+			s.ErrorPassive = true
+		case d[1]&(canErrCtrlRxWarn|canErrCtrlTxWarn) != 0:
+			e.State = STATE_WARNING
+			s.ErrorWarning, s.ErrorPassive = true, false
+		case d[1]&canErrCtrlActive != 0:
+			e.State = STATE_ACTIVE
+			s.ErrorWarning, s.ErrorPassive = false, false
+		}
+	}
+	if f.ID&canErrBusOff != 0 {
+		e.State = STATE_BUS_OFF
+	}
+	// The controller restarted from bus-off as error active
+	if f.ID&canErrRestarted != 0 {
+		e.State = STATE_ACTIVE
+		s.ErrorWarning, s.ErrorPassive = false, false
+	}
+	if f.ID&canErrCnt != 0 {
+		e.TxErrors, e.RxErrors = int(d[6]), int(d[7])
+	}
+	return e, s
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+
+package slcansvc
+
+import "context"
+
+// socketCANBackend stands in for the SocketCAN backend, which is only
+// available on Linux.
+type socketCANBackend struct {
+	*Backend
+}
+
+// NewSocketCANBackend returns a backend whose Handler fails, SocketCAN is
+// only available on Linux.
+func NewSocketCANBackend(d Database, options ...BackendOption) IBackend {
+	return &socketCANBackend{Backend: NewBackend(d, options...).(*Backend)}
+}
+
+func (b *socketCANBackend) Handler(port string, baud int, url string) error {
+	return b.Run(context.Background(), port, baud, url)
+}
+
+// Run closes the backend right away, as a channel which never came up, so
+// that requests are turned away rather than waiting for a Handler
+func (b *socketCANBackend) Run(ctx context.Context, port string, baud int, url string) error {
+	b.setLink(LINK_CLOSED)
+	b.hold.Lock()
+	b.sched.close()
+	return ErrBackendUnsupported
+}
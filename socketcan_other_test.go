@@ -0,0 +1,22 @@
+//go:build !linux
+
+package slcansvc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSocketCANBackendUnsupported(t *testing.T) {
+	b := NewSocketCANBackend(NewDatabase())
+	assert.Equal(t, ErrBackendUnsupported, b.Run(context.Background(), SOCKETCAN_SCHEME+"vcan0", 0, ""))
+
+	// requests are turned away rather than waiting for a Handler
+	assert.Equal(t, ErrBackendClosed, b.PostMessage(Message{ID: 0x111, Data: []byte("AB")}))
+	_, err := b.AddJob(Job{Message: Message{ID: 0x111, Data: []byte("AB")}, Period: 10})
+	assert.Equal(t, ErrBackendClosed, err)
+	st, _ := b.GetStatus()
+	assert.Equal(t, LINK_CLOSED, st.Link)
+}
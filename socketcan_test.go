@@ -0,0 +1,159 @@
+//go:build linux
+
+package slcansvc
+
+import (
+	"net"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestEncapsCANFrame(t *testing.T) {
+	f, n, err := encapsCANFrame(Message{ID: 0x123, Data: []byte("AB")})
+	assert.Equal(t, uint32(0x123), f.ID)
+	assert.Equal(t, uint8(2), f.Len)
+	assert.Equal(t, "AB", string(f.Data[:2]))
+	assert.Equal(t, canFrameSize, n)
+	assert.Equal(t, nil, err)
+
+	f, n, err = encapsCANFrame(Message{ID: 0x12, Extended: true, RTR: true, DLC: 4})
+	assert.Equal(t, uint32(0x12|unix.CAN_EFF_FLAG|unix.CAN_RTR_FLAG), f.ID)
+	assert.Equal(t, uint8(4), f.Len)
+	assert.Equal(t, canFrameSize, n)
+	assert.Equal(t, nil, err)
+
+	f, n, err = encapsCANFrame(Message{ID: 0x123, FD: true, BRS: true, Data: []byte("0123456789AB")})
+	assert.Equal(t, uint8(12), f.Len)
+	assert.Equal(t, uint8(canFDFDF|canFDBRS), f.Flags)
+	assert.Equal(t, 72, n)
+	assert.Equal(t, nil, err)
+
+	// invalid messages
+	_, _, err = encapsCANFrame(Message{ID: 0x20000000})
+	assert.Equal(t, ErrBackendInvalidID, err)
+	_, _, err = encapsCANFrame(Message{ID: 0x123, Data: []byte("012345678")})
+	assert.Equal(t, ErrBackendInvalidData, err)
+	_, _, err = encapsCANFrame(Message{ID: 0x123, FD: true, Data: []byte("012345678")})
+	assert.Equal(t, ErrBackendInvalidData, err)
+	_, _, err = encapsCANFrame(Message{ID: 0x123, BRS: true})
+	assert.Equal(t, ErrBackendInvalidFrame, err)
+}
+
+func TestDecapsCANFrame(t *testing.T) {
+	f, _, _ := encapsCANFrame(Message{ID: 0x12345678, Data: []byte("200rpm")})
+	assert.Equal(t, Message{ID: 0x12345678, Extended: true, Data: []byte("200rpm")}, decapsCANFrame(f, false))
+
+	f, _, _ = encapsCANFrame(Message{ID: 0x7ff, RTR: true, DLC: 8})
+	assert.Equal(t, Message{ID: 0x7ff, RTR: true, DLC: 8}, decapsCANFrame(f, false))
+
+	f, _, _ = encapsCANFrame(Message{ID: 0x123, FD: true, BRS: true, Data: []byte("0123456789AB")})
+	assert.Equal(t, Message{ID: 0x123, FD: true, BRS: true, Data: []byte("0123456789AB")}, decapsCANFrame(f, true))
+}
+
+func TestDecapsCANError(t *testing.T) {
+	var f canFrame
+	f.ID = unix.CAN_ERR_FLAG | canErrAck | canErrProt | canErrCtrl | canErrCnt
+	f.Data[1] = canErrCtrlTxPass
+	f.Data[2] = canErrProtStuff
+	f.Data[3] = canErrProtLocCrc
+	f.Data[6] = 128
+	e, s := decapsCANError(f, StatusFlags{})
+	assert.Equal(t, ErrorEvent{
+		Errors:   []string{ERROR_ACK, ERROR_STUFF, ERROR_CRC},
+		State:    STATE_PASSIVE,
+		TxErrors: 128,
+	}, e)
+	assert.Equal(t, StatusFlags{ErrorPassive: true}, s)
+
+	// flags of the classes an error frame does not report are kept
+	f = canFrame{ID: unix.CAN_ERR_FLAG | canErrLostArb}
+	e, s = decapsCANError(f, s)
+	assert.Equal(t, ErrorEvent{}, e)
+	assert.Equal(t, StatusFlags{ErrorPassive: true, ArbitrationLost: true}, s)
+
+	f = canFrame{ID: unix.CAN_ERR_FLAG | canErrBusOff}
+	e, s = decapsCANError(f, s)
+	assert.Equal(t, ErrorEvent{State: STATE_BUS_OFF}, e)
+	assert.Equal(t, StatusFlags{ErrorPassive: true, ArbitrationLost: true}, s)
+
+	f = canFrame{ID: unix.CAN_ERR_FLAG | canErrRestarted}
+	e, s = decapsCANError(f, s)
+	assert.Equal(t, ErrorEvent{State: STATE_ACTIVE}, e)
+	assert.Equal(t, StatusFlags{ArbitrationLost: true}, s)
+
+	f = canFrame{ID: unix.CAN_ERR_FLAG | canErrCtrl}
+	f.Data[1] = canErrCtrlRxWarn
+	_, s = decapsCANError(f, StatusFlags{ErrorPassive: true})
+	assert.Equal(t, StatusFlags{ErrorWarning: true}, s)
+}
+
+func TestCANRawFilters(t *testing.T) {
+	assert.Equal(t, []unix.CanFilter{{Id: 0, Mask: 0}}, canRawFilters(nil))
+	assert.Equal(t, []unix.CanFilter{
+		{Id: 0x100, Mask: 0x700 | unix.CAN_EFF_FLAG},
+		{Id: 0x12340000 | unix.CAN_EFF_FLAG, Mask: 0x1fff0000 | unix.CAN_EFF_FLAG},
+		{Id: 0, Mask: 0},
+	}, canRawFilters([]Filter{
+		{ID: 0x123, Mask: 0x700, Type: FILTER_STANDARD},
+		{ID: 0x12345678, Mask: 0x1fff0000, Type: FILTER_EXTENDED},
+		// ranges are left to the software filters
+		{From: 0x100, To: 0x1ff},
+	}))
+}
+
+// vcanSocket opens a raw socket on vcan0, skipping the test when the
+// interface does not exist
+func vcanSocket(t *testing.T) int {
+	ifi, err := net.InterfaceByName("vcan0")
+	if err != nil {
+		t.Skip("vcan0 not available")
+	}
+	fd, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, unix.CAN_RAW)
+	if err != nil {
+		t.Skip("SocketCAN not available")
+	}
+	assert.Equal(t, nil, unix.Bind(fd, &unix.SockaddrCAN{Ifindex: ifi.Index}))
+	tv := unix.NsecToTimeval(time.Second.Nanoseconds())
+	assert.Equal(t, nil, unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv))
+	return fd
+}
+
+func TestSocketCANBackend(t *testing.T) {
+	fd := vcanSocket(t)
+	defer unix.Close(fd)
+
+	db := NewDatabase()
+	b := NewSocketCANBackend(db, WithStatusInterval(0))
+	go b.Handler(SOCKETCAN_SCHEME+"vcan0", 0, "")
+	assert.Eventually(t, func() bool {
+		return b.PostMessage(Message{ID: 0x456, Data: []byte("AB")}) == nil
+	}, time.Second, 10*time.Millisecond)
+
+	// transmitted frames reach other sockets on the interface
+	var f canFrame
+	n, err := unix.Read(fd, (*[unsafe.Sizeof(f)]byte)(unsafe.Pointer(&f))[:])
+	assert.Equal(t, nil, err)
+	assert.Equal(t, canFrameSize, n)
+	assert.Equal(t, Message{ID: 0x456, Data: []byte("AB")}, decapsCANFrame(f, false))
+
+	// received frames are stored, unless filtered out
+	assert.Equal(t, nil, b.SetFilters([]Filter{{ID: 0x654, Mask: 0x7ff}}))
+	for _, m := range []Message{{ID: 0x655, Data: []byte("X")}, {ID: 0x654, Data: []byte("ABC")}} {
+		f, n, _ = encapsCANFrame(m)
+		_, err = unix.Write(fd, (*[unsafe.Sizeof(f)]byte)(unsafe.Pointer(&f))[:n])
+		assert.Equal(t, nil, err)
+	}
+	assert.Eventually(t, func() bool {
+		m, err := db.GetRx(0x654)
+		return err == nil && string(m.Data) == "ABC"
+	}, time.Second, time.Millisecond)
+	_, err = db.GetRx(0x655)
+	assert.Equal(t, ErrDatabaseNotFound, err)
+
+	// SLCAN device requests are not supported
+	assert.Equal(t, ErrBackendUnsupported, b.SetBitrate(Bitrate{Rate: 500000}))
+}
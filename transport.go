@@ -3,11 +3,17 @@ package slcansvc
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-kit/kit/transport"
 	httptransport "github.com/go-kit/kit/transport/http"
@@ -20,58 +26,284 @@ var (
 	// ErrTransportBadRouting is returned when an expected path variable is missing.
 	// It always indicates programmer error.
 	ErrTransportBadRouting = errors.New("Transport: bad routing")
+	// ErrTransportInvalidEncoding is returned for an unknown encoding of
+	// message data.
+	ErrTransportInvalidEncoding = errors.New("Transport: invalid encoding")
 )
 
 func MakeHTTPHandler(s IService, logger log.Logger) http.Handler {
 	r := mux.NewRouter()
+	r.Use(negotiateEncoding)
+	registerRoutes(r, "/slcan", s, logger)
+	r.PathPrefix("/slcan/docs").Handler(httpSwagger.WrapHandler)
+
+	return r
+}
+
+// MakeChannelsHTTPHandler serves every channel under /slcan/{channel}, and
+// the default channel under /slcan as well. Channel names must be valid,
+// see ParseChannel.
+func MakeChannelsHTTPHandler(channels map[string]IService, def string, logger log.Logger) http.Handler {
+	r := mux.NewRouter()
+	r.Use(negotiateEncoding)
+	for name, s := range channels {
+		registerRoutes(r, "/slcan/"+name, s, log.With(logger, "channel", name))
+	}
+	registerRoutes(r, "/slcan", channels[def], log.With(logger, "channel", def))
+	r.PathPrefix("/slcan/docs").Handler(httpSwagger.WrapHandler)
+
+	return r
+}
+
+// registerRoutes serves the service on r under prefix
+func registerRoutes(r *mux.Router, prefix string, s IService, logger log.Logger) {
 	e := MakeServerEndpoints(s)
 	options := []httptransport.ServerOption{
 		httptransport.ServerErrorHandler(transport.NewLogErrorHandler(logger)),
 		httptransport.ServerErrorEncoder(encodeError),
 	}
 
-	r.Methods("GET").Path("/slcan/{id}").Handler(httptransport.NewServer(
-		e.GetMessageEndpoint,
-		DecodeGetMessageRequest,
+	r.Methods("GET").Path(prefix + "/tx/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.GetTxMessageEndpoint,
+		DecodeGetTxMessageRequest,
 		EncodeResponse,
 		options...,
 	))
-	r.Methods("POST").Path("/slcan").Handler(httptransport.NewServer(
-		e.PostMessageEndpoint,
-		DecodePostMessageRequest,
+	r.Methods("POST").Path(prefix + "/tx").Handler(httptransport.NewServer(
+		e.PostTxMessageEndpoint,
+		DecodePostTxMessageRequest,
 		EncodeResponse,
 		options...,
 	))
-	r.Methods("PUT").Path("/slcan/{id}").Handler(httptransport.NewServer(
-		e.PutMessageEndpoint,
-		DecodePutMessageRequest,
+	r.Methods("PUT").Path(prefix + "/tx/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.PutTxMessageEndpoint,
+		DecodePutTxMessageRequest,
 		EncodeResponse,
 		options...,
 	))
-	r.Methods("DELETE").Path("/slcan/{id}").Handler(httptransport.NewServer(
-		e.DeleteMessageEndpoint,
-		DecodeDeleteMessageRequest,
+	r.Methods("DELETE").Path(prefix + "/tx/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.DeleteTxMessageEndpoint,
+		DecodeDeleteTxMessageRequest,
 		EncodeResponse,
 		options...,
 	))
-	r.Methods("POST").Path("/slcan/reboot").Handler(httptransport.NewServer(
+	r.Methods("POST").Path(prefix + "/{id:[0-9]+}/rtr").Handler(httptransport.NewServer(
+		e.RequestMessageEndpoint,
+		DecodeRequestMessageRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path(prefix + "/reboot").Handler(httptransport.NewServer(
 		e.RebootEndpoint,
 		DecodeRebootRequest,
 		EncodeResponse,
 		options...,
 	))
-	r.Methods("POST").Path("/slcan/unlock").Handler(httptransport.NewServer(
+	r.Methods("POST").Path(prefix + "/unlock").Handler(httptransport.NewServer(
 		e.UnlockEndpoint,
 		DecodeUnlockRequest,
 		EncodeResponse,
 		options...,
 	))
-	r.PathPrefix("/slcan/docs").Handler(httpSwagger.WrapHandler)
+	r.Methods("GET").Path(prefix + "/config/bitrate").Handler(httptransport.NewServer(
+		e.GetBitrateEndpoint,
+		DecodeGetBitrateRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path(prefix + "/config/bitrate").Handler(httptransport.NewServer(
+		e.SetBitrateEndpoint,
+		DecodeSetBitrateRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/config/acceptance").Handler(httptransport.NewServer(
+		e.GetAcceptanceEndpoint,
+		DecodeGetAcceptanceRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path(prefix + "/config/acceptance").Handler(httptransport.NewServer(
+		e.SetAcceptanceEndpoint,
+		DecodeSetAcceptanceRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/config/filters").Handler(httptransport.NewServer(
+		e.GetFiltersEndpoint,
+		DecodeGetFiltersRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path(prefix + "/config/filters").Handler(httptransport.NewServer(
+		e.SetFiltersEndpoint,
+		DecodeSetFiltersRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path(prefix + "/config/mode").Handler(httptransport.NewServer(
+		e.SetModeEndpoint,
+		DecodeSetModeRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/status").Handler(httptransport.NewServer(
+		e.GetStatusEndpoint,
+		DecodeGetStatusRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/device").Handler(httptransport.NewServer(
+		e.GetDeviceEndpoint,
+		DecodeGetDeviceRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/errors").Handler(httptransport.NewServer(
+		e.GetErrorsEndpoint,
+		DecodeGetErrorsRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("DELETE").Path(prefix + "/errors").Handler(httptransport.NewServer(
+		e.ClearErrorsEndpoint,
+		DecodeClearErrorsRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/jobs").Handler(httptransport.NewServer(
+		e.GetJobsEndpoint,
+		DecodeGetJobsRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path(prefix + "/jobs").Handler(httptransport.NewServer(
+		e.PostJobEndpoint,
+		DecodePostJobRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/jobs/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.GetJobEndpoint,
+		DecodeGetJobRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("PUT").Path(prefix + "/jobs/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.PutJobEndpoint,
+		DecodePutJobRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("DELETE").Path(prefix + "/jobs/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.DeleteJobEndpoint,
+		DecodeDeleteJobRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path(prefix + "/jobs/{id:[0-9]+}/start").Handler(httptransport.NewServer(
+		e.StartJobEndpoint,
+		DecodeStartJobRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("POST").Path(prefix + "/jobs/{id:[0-9]+}/stop").Handler(httptransport.NewServer(
+		e.StopJobEndpoint,
+		DecodeStopJobRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/rx/{id:[0-9]+}/history").Handler(httptransport.NewServer(
+		e.GetHistoryEndpoint,
+		DecodeGetHistoryRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/history").Handler(httptransport.NewServer(
+		e.GetHistoryStatsEndpoint,
+		DecodeGetHistoryStatsRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/tx").Handler(httptransport.NewServer(
+		e.ListTxMessagesEndpoint,
+		DecodeListTxMessagesRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/rx").Handler(httptransport.NewServer(
+		e.ListRxMessagesEndpoint,
+		DecodeListRxMessagesRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("GET").Path(prefix + "/rx/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.GetRxMessageEndpoint,
+		DecodeGetRxMessageRequest,
+		EncodeResponse,
+		options...,
+	))
+	r.Methods("DELETE").Path(prefix + "/rx/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.DeleteRxMessageEndpoint,
+		DecodeDeleteRxMessageRequest,
+		EncodeResponse,
+		options...,
+	))
 
-	return r
+	// Deprecated routes from before the TX table and the RX cache were
+	// separated, reading the RX cache and writing the TX table
+	deprecated := append([]httptransport.ServerOption{
+		httptransport.ServerAfter(func(ctx context.Context, w http.ResponseWriter) context.Context {
+			w.Header().Set("Deprecation", "true")
+			return ctx
+		}),
+	}, options...)
+	r.Methods("GET").Path(prefix + "/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.GetRxMessageEndpoint,
+		DecodeGetRxMessageRequest,
+		EncodeResponse,
+		deprecated...,
+	))
+	r.Methods("POST").Path(prefix).Handler(httptransport.NewServer(
+		e.PostTxMessageEndpoint,
+		DecodePostTxMessageRequest,
+		EncodeResponse,
+		deprecated...,
+	))
+	r.Methods("PUT").Path(prefix + "/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.PutTxMessageEndpoint,
+		DecodePutTxMessageRequest,
+		EncodeResponse,
+		deprecated...,
+	))
+	r.Methods("DELETE").Path(prefix + "/{id:[0-9]+}").Handler(httptransport.NewServer(
+		e.DeleteTxMessageEndpoint,
+		DecodeDeleteTxMessageRequest,
+		EncodeResponse,
+		deprecated...,
+	))
+	r.Methods("GET").Path(prefix).Handler(httptransport.NewServer(
+		e.ListRxMessagesEndpoint,
+		DecodeListRxMessagesRequest,
+		EncodeResponse,
+		deprecated...,
+	))
+}
+
+func DecodeGetTxMessageRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrTransportBadRouting
+	}
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrTransportBadRouting
+	}
+	return getTxMessageRequest{ID: i}, nil
 }
 
-func DecodeGetMessageRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+func DecodeGetRxMessageRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
@@ -81,45 +313,79 @@ func DecodeGetMessageRequest(_ context.Context, r *http.Request) (request interf
 	if err != nil {
 		return nil, ErrTransportBadRouting
 	}
-	return getMessageRequest{ID: i}, nil
+	return getRxMessageRequest{ID: i}, nil
 }
 
-func DecodePostMessageRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
-	var req postMessageRequest
+func DecodePostTxMessageRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	var req postTxMessageRequest
+	req.Msg.encoding = requestEncoding(ctx)
 	if e := json.NewDecoder(r.Body).Decode(&req.Msg); e != nil {
 		return nil, e
 	}
+	req.Msg.encoding = ""
 	return req, nil
 }
 
-func DecodePutMessageRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+func DecodePutTxMessageRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
 		return nil, ErrTransportBadRouting
 	}
-	var msg Message
+	msg := Message{encoding: requestEncoding(ctx)}
 	if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
 		return nil, err
 	}
+	msg.encoding = ""
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrTransportBadRouting
+	}
+	return putTxMessageRequest{ID: i, Msg: msg}, nil
+}
+
+func DecodeDeleteTxMessageRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrTransportBadRouting
+	}
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrTransportBadRouting
+	}
+	return deleteTxMessageRequest{ID: i}, nil
+}
+
+func DecodeDeleteRxMessageRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrTransportBadRouting
+	}
 	i, err := strconv.Atoi(id)
 	if err != nil {
 		return nil, ErrTransportBadRouting
 	}
-	return putMessageRequest{ID: i, Msg: msg}, nil
+	return deleteRxMessageRequest{ID: i}, nil
 }
 
-func DecodeDeleteMessageRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+func DecodeRequestMessageRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
 		return nil, ErrTransportBadRouting
 	}
+	var req RemoteRequest
+	// Request body is optional, defaults to dlc 0 without waiting
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		return nil, err
+	}
 	i, err := strconv.Atoi(id)
 	if err != nil {
 		return nil, ErrTransportBadRouting
 	}
-	return deleteMessageRequest{ID: i}, nil
+	return requestMessageRequest{ID: i, Req: req}, nil
 }
 
 func DecodeRebootRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
@@ -130,109 +396,899 @@ func DecodeUnlockRequest(_ context.Context, r *http.Request) (request interface{
 	return unlockRequest{}, nil
 }
 
-func EncodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
-	if e, ok := response.(errorer); ok && e.error() != nil {
-		// Not a Go kit transport error, but a business-logic error.
-		// Provide those as HTTP errors.
-		encodeError(ctx, e.error(), w)
-		return nil
+func DecodeGetBitrateRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return getBitrateRequest{}, nil
+}
+
+func DecodeSetBitrateRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req setBitrateRequest
+	if e := json.NewDecoder(r.Body).Decode(&req.Bitrate); e != nil {
+		return nil, e
 	}
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	return json.NewEncoder(w).Encode(response)
+	return req, nil
 }
 
-func EncodeGetMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
-	// r.Methods("GET").Path("/slcan/{id}")
-	r := request.(getMessageRequest)
-	id := strconv.Itoa(r.ID)
-	req.URL.Path = "/slcan/" + id
-	return encodeRequest(ctx, req, nil)
+func DecodeGetAcceptanceRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return getAcceptanceRequest{}, nil
 }
 
-func EncodePostMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
-	// r.Methods("POST").Path("/slcan")
-	req.URL.Path = "/slcan"
-	return encodeRequest(ctx, req, request)
+func DecodeSetAcceptanceRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req setAcceptanceRequest
+	if e := json.NewDecoder(r.Body).Decode(&req.Acceptance); e != nil {
+		return nil, e
+	}
+	return req, nil
 }
 
-func EncodePutMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
-	// r.Methods("PUT").Path("/slcan/{id}")
-	r := request.(putMessageRequest)
-	id := strconv.Itoa(r.ID)
-	req.URL.Path = "/slcan/" + id
-	return encodeRequest(ctx, req, request)
+func DecodeGetFiltersRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return getFiltersRequest{}, nil
 }
 
-func EncodeDeleteMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
-	// r.Methods("DELETE").Path("/slcan/{id}")
-	r := request.(deleteMessageRequest)
-	id := strconv.Itoa(r.ID)
-	req.URL.Path = "/slcan/" + id
-	return encodeRequest(ctx, req, request)
+func DecodeSetFiltersRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req setFiltersRequest
+	if e := json.NewDecoder(r.Body).Decode(&req.Filters); e != nil {
+		return nil, e
+	}
+	return req, nil
 }
 
-func EncodeRebootRequest(ctx context.Context, req *http.Request, request interface{}) error {
-	// r.Methods("POST").Path("/slcan/reboot")
-	req.URL.Path = "/slcan/reboot"
-	return encodeRequest(ctx, req, request)
+func DecodeSetModeRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req setModeRequest
+	if e := json.NewDecoder(r.Body).Decode(&req); e != nil {
+		return nil, e
+	}
+	return req, nil
 }
 
-func EncodeUnlockRequest(ctx context.Context, req *http.Request, request interface{}) error {
-	// r.Methods("POST").Path("/slcan/unlock")
-	req.URL.Path = "/slcan/unlock"
-	return encodeRequest(ctx, req, request)
+func DecodeGetStatusRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return getStatusRequest{}, nil
 }
 
-func DecodeGetMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
-	if r.StatusCode != http.StatusOK {
-		return nil, errors.New(r.Status)
+func DecodeGetDeviceRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	var req getDeviceRequest
+	if v := r.URL.Query().Get("refresh"); v != "" {
+		refresh, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, ErrTransportBadRouting
+		}
+		req.Refresh = refresh
 	}
-	var resp getMessageResponse
-	err := json.NewDecoder(r.Body).Decode(&resp)
-	return resp, err
+	return req, nil
 }
 
-func DecodePostMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
-	if r.StatusCode != http.StatusOK {
-		return nil, errors.New(r.Status)
+func DecodeGetErrorsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return getErrorsRequest{}, nil
+}
+
+func DecodeClearErrorsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return clearErrorsRequest{}, nil
+}
+
+func DecodeGetJobsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return getJobsRequest{}, nil
+}
+
+func DecodePostJobRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	var req postJobRequest
+	req.Job.Message.encoding = requestEncoding(ctx)
+	if e := json.NewDecoder(r.Body).Decode(&req.Job); e != nil {
+		return nil, e
 	}
-	var resp postMessageResponse
-	err := json.NewDecoder(r.Body).Decode(&resp)
-	return resp, err
+	req.Job.Message.encoding = ""
+	return req, nil
 }
 
-func DecodePutMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
-	if r.StatusCode != http.StatusOK {
-		return nil, errors.New(r.Status)
+func DecodeGetJobRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrTransportBadRouting
 	}
-	var resp putMessageResponse
-	err := json.NewDecoder(r.Body).Decode(&resp)
-	return resp, err
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrTransportBadRouting
+	}
+	return getJobRequest{ID: i}, nil
 }
 
-func DecodeDeleteMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
-	if r.StatusCode != http.StatusOK {
-		return nil, errors.New(r.Status)
+func DecodePutJobRequest(ctx context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrTransportBadRouting
 	}
-	var resp deleteMessageResponse
-	err := json.NewDecoder(r.Body).Decode(&resp)
-	return resp, err
+	j := Job{Message: Message{encoding: requestEncoding(ctx)}}
+	if err := json.NewDecoder(r.Body).Decode(&j); err != nil {
+		return nil, err
+	}
+	j.Message.encoding = ""
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrTransportBadRouting
+	}
+	return putJobRequest{ID: i, Job: j}, nil
 }
 
-func DecodeRebootResponse(_ context.Context, r *http.Response) (interface{}, error) {
-	if r.StatusCode != http.StatusOK {
-		return nil, errors.New(r.Status)
+func DecodeDeleteJobRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrTransportBadRouting
 	}
-	var resp rebootResponse
-	err := json.NewDecoder(r.Body).Decode(&resp)
-	return resp, err
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrTransportBadRouting
+	}
+	return deleteJobRequest{ID: i}, nil
 }
 
-func DecodeUnlockResponse(_ context.Context, r *http.Response) (interface{}, error) {
-	if r.StatusCode != http.StatusOK {
-		return nil, errors.New(r.Status)
+func DecodeStartJobRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrTransportBadRouting
 	}
-	var resp unlockResponse
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrTransportBadRouting
+	}
+	return startJobRequest{ID: i}, nil
+}
+
+func DecodeStopJobRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrTransportBadRouting
+	}
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrTransportBadRouting
+	}
+	return stopJobRequest{ID: i}, nil
+}
+
+func DecodeGetHistoryRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, ErrTransportBadRouting
+	}
+	i, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, ErrTransportBadRouting
+	}
+	req := getHistoryRequest{ID: i}
+	q := r.URL.Query()
+	if v := q.Get("since"); v != "" {
+		if req.Since, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return nil, ErrTransportBadRouting
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if req.Limit, err = strconv.Atoi(v); err != nil {
+			return nil, ErrTransportBadRouting
+		}
+	}
+	return req, nil
+}
+
+func DecodeGetHistoryStatsRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	return getHistoryStatsRequest{}, nil
+}
+
+func DecodeListTxMessagesRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	q, err := decodeMessageQuery(r)
+	if err != nil {
+		return nil, err
+	}
+	return listTxMessagesRequest{Query: q}, nil
+}
+
+func DecodeListRxMessagesRequest(_ context.Context, r *http.Request) (request interface{}, err error) {
+	q, err := decodeMessageQuery(r)
+	if err != nil {
+		return nil, err
+	}
+	return listRxMessagesRequest{Query: q}, nil
+}
+
+// decodeMessageQuery parses the filters, sorting and paging of a listing
+func decodeMessageQuery(r *http.Request) (q MessageQuery, err error) {
+	v := r.URL.Query()
+	for _, p := range []struct {
+		name string
+		id   *uint32
+	}{{"from", &q.From}, {"to", &q.To}} {
+		if s := v.Get(p.name); s != "" {
+			id, err := strconv.ParseUint(s, 0, 32)
+			if err != nil {
+				return q, ErrTransportBadRouting
+			}
+			*p.id = uint32(id)
+		}
+	}
+	if s := v.Get("since"); s != "" {
+		if q.Since, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return q, ErrTransportBadRouting
+		}
+	}
+	if q.Mask, err = hex.DecodeString(v.Get("mask")); err != nil {
+		return q, ErrTransportBadRouting
+	}
+	if q.Match, err = hex.DecodeString(v.Get("match")); err != nil {
+		return q, ErrTransportBadRouting
+	}
+	if q.Data, err = hex.DecodeString(v.Get("data")); err != nil {
+		return q, ErrTransportBadRouting
+	}
+	for _, p := range []struct {
+		name string
+		n    *int
+	}{{"offset", &q.Offset}, {"limit", &q.Limit}} {
+		if s := v.Get(p.name); s != "" {
+			if *p.n, err = strconv.Atoi(s); err != nil {
+				return q, ErrTransportBadRouting
+			}
+		}
+	}
+	q.Type, q.Sort = v.Get("type"), v.Get("sort")
+	return q, nil
+}
+
+func EncodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if e, ok := response.(errorer); ok && e.error() != nil {
+		// Not a Go kit transport error, but a business-logic error.
+		// Provide those as HTTP errors.
+		encodeError(ctx, e.error(), w)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	return json.NewEncoder(w).Encode(encodeData(response, responseEncoding(ctx)))
+}
+
+type contextKey int
+
+const (
+	contextKeyRequestEncoding contextKey = iota
+	contextKeyResponseEncoding
+)
+
+// negotiateEncoding selects the encodings of message data in the request
+// and response bodies: the encoding query parameter sets both, otherwise
+// the encoding parameter of the Content-Type and Accept media types, hex
+// by default.
+func negotiateEncoding(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req, resp := r.URL.Query().Get("encoding"), ""
+		if req != "" {
+			resp = req
+		} else {
+			req, resp = mediaEncoding(r.Header.Get("Content-Type")), mediaEncoding(r.Header.Get("Accept"))
+		}
+		for _, enc := range []string{req, resp} {
+			if enc != "" && !ValidEncoding(enc) {
+				encodeError(r.Context(), ErrTransportInvalidEncoding, w)
+				return
+			}
+		}
+		ctx := context.WithValue(r.Context(), contextKeyRequestEncoding, req)
+		ctx = context.WithValue(ctx, contextKeyResponseEncoding, resp)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// mediaEncoding returns the encoding parameter of the first media type in
+// header carrying one
+func mediaEncoding(header string) string {
+	for _, s := range strings.Split(header, ",") {
+		if _, params, err := mime.ParseMediaType(s); err == nil && params["encoding"] != "" {
+			return params["encoding"]
+		}
+	}
+	return ""
+}
+
+func requestEncoding(ctx context.Context) string {
+	enc, _ := ctx.Value(contextKeyRequestEncoding).(string)
+	return enc
+}
+
+func responseEncoding(ctx context.Context) string {
+	enc, _ := ctx.Value(contextKeyResponseEncoding).(string)
+	return enc
+}
+
+// encodeData sets the encoding of the message data carried by response,
+// messages are copied not to alter those of the service
+func encodeData(response interface{}, enc string) interface{} {
+	if enc == "" {
+		return response
+	}
+	messages := func(l []Message) []Message {
+		c := make([]Message, len(l))
+		for i, m := range l {
+			m.encoding = enc
+			c[i] = m
+		}
+		return c
+	}
+	switch r := response.(type) {
+	case getTxMessageResponse:
+		r.Msg.encoding = enc
+		return r
+	case getRxMessageResponse:
+		r.Msg.encoding = enc
+		return r
+	case requestMessageResponse:
+		r.Msg.encoding = enc
+		return r
+	case listTxMessagesResponse:
+		r.Messages = messages(r.Messages)
+		return r
+	case listRxMessagesResponse:
+		r.Messages = messages(r.Messages)
+		return r
+	case getHistoryResponse:
+		r.History = messages(r.History)
+		return r
+	case getJobsResponse:
+		jobs := make([]Job, len(r.Jobs))
+		for i, j := range r.Jobs {
+			j.Message.encoding = enc
+			jobs[i] = j
+		}
+		r.Jobs = jobs
+		return r
+	case postJobResponse:
+		r.Job.Message.encoding = enc
+		return r
+	case getJobResponse:
+		r.Job.Message.encoding = enc
+		return r
+	case putJobResponse:
+		r.Job.Message.encoding = enc
+		return r
+	case startJobResponse:
+		r.Job.Message.encoding = enc
+		return r
+	case stopJobResponse:
+		r.Job.Message.encoding = enc
+		return r
+	}
+	return response
+}
+
+func EncodeGetTxMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/tx/{id}")
+	r := request.(getTxMessageRequest)
+	id := strconv.Itoa(r.ID)
+	req.URL.Path = "/slcan/tx/" + id
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeGetRxMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/rx/{id}")
+	r := request.(getRxMessageRequest)
+	id := strconv.Itoa(r.ID)
+	req.URL.Path = "/slcan/rx/" + id
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodePostTxMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/tx")
+	req.URL.Path = "/slcan/tx"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodePutTxMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("PUT").Path("/slcan/tx/{id}")
+	r := request.(putTxMessageRequest)
+	id := strconv.Itoa(r.ID)
+	req.URL.Path = "/slcan/tx/" + id
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeDeleteTxMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("DELETE").Path("/slcan/tx/{id}")
+	r := request.(deleteTxMessageRequest)
+	id := strconv.Itoa(r.ID)
+	req.URL.Path = "/slcan/tx/" + id
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeDeleteRxMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("DELETE").Path("/slcan/rx/{id}")
+	r := request.(deleteRxMessageRequest)
+	id := strconv.Itoa(r.ID)
+	req.URL.Path = "/slcan/rx/" + id
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeRequestMessageRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/{id}/rtr")
+	r := request.(requestMessageRequest)
+	id := strconv.Itoa(r.ID)
+	req.URL.Path = "/slcan/" + id + "/rtr"
+	return encodeRequest(ctx, req, r.Req)
+}
+
+func EncodeRebootRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/reboot")
+	req.URL.Path = "/slcan/reboot"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeUnlockRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/unlock")
+	req.URL.Path = "/slcan/unlock"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeGetBitrateRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/config/bitrate")
+	req.URL.Path = "/slcan/config/bitrate"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeSetBitrateRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/config/bitrate")
+	r := request.(setBitrateRequest)
+	req.URL.Path = "/slcan/config/bitrate"
+	return encodeRequest(ctx, req, r.Bitrate)
+}
+
+func EncodeGetAcceptanceRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/config/acceptance")
+	req.URL.Path = "/slcan/config/acceptance"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeSetAcceptanceRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/config/acceptance")
+	r := request.(setAcceptanceRequest)
+	req.URL.Path = "/slcan/config/acceptance"
+	return encodeRequest(ctx, req, r.Acceptance)
+}
+
+func EncodeGetFiltersRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/config/filters")
+	req.URL.Path = "/slcan/config/filters"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeSetFiltersRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/config/filters")
+	r := request.(setFiltersRequest)
+	req.URL.Path = "/slcan/config/filters"
+	return encodeRequest(ctx, req, r.Filters)
+}
+
+func EncodeSetModeRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/config/mode")
+	req.URL.Path = "/slcan/config/mode"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeGetStatusRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/status")
+	req.URL.Path = "/slcan/status"
+	return encodeRequest(ctx, req, request)
+}
+
+func EncodeGetDeviceRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/device")
+	r := request.(getDeviceRequest)
+	req.URL.Path = "/slcan/device"
+	if r.Refresh {
+		req.URL.RawQuery = "refresh=true"
+	}
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeGetErrorsRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/errors")
+	req.URL.Path = "/slcan/errors"
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeClearErrorsRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("DELETE").Path("/slcan/errors")
+	req.URL.Path = "/slcan/errors"
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeGetJobsRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/jobs")
+	req.URL.Path = "/slcan/jobs"
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodePostJobRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/jobs")
+	r := request.(postJobRequest)
+	req.URL.Path = "/slcan/jobs"
+	return encodeRequest(ctx, req, r.Job)
+}
+
+func EncodeGetJobRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/jobs/{id}")
+	r := request.(getJobRequest)
+	req.URL.Path = "/slcan/jobs/" + strconv.Itoa(r.ID)
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodePutJobRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("PUT").Path("/slcan/jobs/{id}")
+	r := request.(putJobRequest)
+	req.URL.Path = "/slcan/jobs/" + strconv.Itoa(r.ID)
+	return encodeRequest(ctx, req, r.Job)
+}
+
+func EncodeDeleteJobRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("DELETE").Path("/slcan/jobs/{id}")
+	r := request.(deleteJobRequest)
+	req.URL.Path = "/slcan/jobs/" + strconv.Itoa(r.ID)
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeStartJobRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/jobs/{id}/start")
+	r := request.(startJobRequest)
+	req.URL.Path = "/slcan/jobs/" + strconv.Itoa(r.ID) + "/start"
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeStopJobRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("POST").Path("/slcan/jobs/{id}/stop")
+	r := request.(stopJobRequest)
+	req.URL.Path = "/slcan/jobs/" + strconv.Itoa(r.ID) + "/stop"
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeGetHistoryRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/rx/{id}/history")
+	r := request.(getHistoryRequest)
+	req.URL.Path = "/slcan/rx/" + strconv.Itoa(r.ID) + "/history"
+	q := url.Values{}
+	if !r.Since.IsZero() {
+		q.Set("since", r.Since.Format(time.RFC3339Nano))
+	}
+	if r.Limit > 0 {
+		q.Set("limit", strconv.Itoa(r.Limit))
+	}
+	req.URL.RawQuery = q.Encode()
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeGetHistoryStatsRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/history")
+	req.URL.Path = "/slcan/history"
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeListTxMessagesRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/tx")
+	req.URL.Path = "/slcan/tx"
+	req.URL.RawQuery = encodeMessageQuery(request.(listTxMessagesRequest).Query).Encode()
+	return encodeRequest(ctx, req, nil)
+}
+
+func EncodeListRxMessagesRequest(ctx context.Context, req *http.Request, request interface{}) error {
+	// r.Methods("GET").Path("/slcan/rx")
+	req.URL.Path = "/slcan/rx"
+	req.URL.RawQuery = encodeMessageQuery(request.(listRxMessagesRequest).Query).Encode()
+	return encodeRequest(ctx, req, nil)
+}
+
+// encodeMessageQuery formats the filters, sorting and paging of a listing
+func encodeMessageQuery(q MessageQuery) url.Values {
+	v := url.Values{}
+	if q.From != 0 {
+		v.Set("from", strconv.FormatUint(uint64(q.From), 10))
+	}
+	if q.To != 0 {
+		v.Set("to", strconv.FormatUint(uint64(q.To), 10))
+	}
+	if !q.Since.IsZero() {
+		v.Set("since", q.Since.Format(time.RFC3339Nano))
+	}
+	if len(q.Mask) > 0 {
+		v.Set("mask", hex.EncodeToString(q.Mask))
+		v.Set("match", hex.EncodeToString(q.Match))
+	}
+	if len(q.Data) > 0 {
+		v.Set("data", hex.EncodeToString(q.Data))
+	}
+	for name, s := range map[string]string{"type": q.Type, "sort": q.Sort} {
+		if s != "" {
+			v.Set(name, s)
+		}
+	}
+	if q.Offset != 0 {
+		v.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Limit != 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return v
+}
+
+func DecodeGetTxMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getTxMessageResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetRxMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getRxMessageResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodePostTxMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp postTxMessageResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodePutTxMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp putTxMessageResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeDeleteTxMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp deleteTxMessageResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeDeleteRxMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp deleteRxMessageResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeRequestMessageResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp requestMessageResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeRebootResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp rebootResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeUnlockResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp unlockResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetBitrateResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getBitrateResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeSetBitrateResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp setBitrateResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetAcceptanceResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getAcceptanceResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeSetAcceptanceResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp setAcceptanceResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetFiltersResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getFiltersResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeSetFiltersResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp setFiltersResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeSetModeResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp setModeResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetStatusResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getStatusResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetDeviceResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getDeviceResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetErrorsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getErrorsResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeClearErrorsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp clearErrorsResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetJobsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getJobsResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodePostJobResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp postJobResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetJobResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getJobResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodePutJobResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp putJobResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeDeleteJobResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp deleteJobResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeStartJobResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp startJobResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeStopJobResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp stopJobResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetHistoryResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getHistoryResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeGetHistoryStatsResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp getHistoryStatsResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeListTxMessagesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp listTxMessagesResponse
+	err := json.NewDecoder(r.Body).Decode(&resp)
+	return resp, err
+}
+
+func DecodeListRxMessagesResponse(_ context.Context, r *http.Response) (interface{}, error) {
+	if r.StatusCode != http.StatusOK {
+		return nil, errors.New(r.Status)
+	}
+	var resp listRxMessagesResponse
 	err := json.NewDecoder(r.Body).Decode(&resp)
 	return resp, err
 }
@@ -267,10 +1323,26 @@ func encodeError(_ context.Context, err error, w http.ResponseWriter) {
 
 func codeFrom(err error) int {
 	switch err {
-	case ErrDatabaseNotFound:
+	case ErrDatabaseNotFound, ErrSchedulerNotFound:
 		return http.StatusNotFound
-	case ErrDatabaseAlreadyExists, ErrTransportBadRouting:
+	case ErrDatabaseAlreadyExists, ErrDatabaseInvalidData, ErrTransportBadRouting,
+		ErrTransportInvalidEncoding, ErrServiceInvalidID, ErrServiceInvalidBitrate,
+		ErrServiceInvalidDLC, ErrServiceInvalidFilter, ErrServiceInvalidMode,
+		ErrServiceInvalidPeriod, ErrServiceInvalidCount, ErrServiceInvalidLimit,
+		ErrServiceInvalidQuery, ErrBackendInvalidID, ErrBackendInvalidData,
+		ErrBackendInvalidFilter, ErrBackendInvalidMode, ErrBackendInvalidRate,
+		ErrBackendInvalidFrame:
 		return http.StatusBadRequest
+	case ErrBackendUnsupported:
+		return http.StatusNotImplemented
+	case ErrBackendListenOnly:
+		return http.StatusConflict
+	case ErrBackendNack:
+		return http.StatusBadGateway
+	case ErrBackendTimeout:
+		return http.StatusGatewayTimeout
+	case ErrBackendOnhold, ErrBackendReconnecting, ErrBackendClosed:
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}